@@ -40,6 +40,53 @@ func dataSourceSkopeo2Inspect() *schema.Resource {
 				Default:     false,
 				Description: "allow access to non-TLS insecure repositories.",
 			},
+			"anonymous": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "access the registry anonymously, ignoring the provider source credentials and any " +
+					"credentials in the authentication file.",
+			},
+			"policy_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Path of a containers-policy.json signature verification policy which the image " +
+					"must satisfy. Overrides provider configuration.",
+				ConflictsWith: []string{"policy_json", "insecure_policy"},
+			},
+			"policy_json": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Signature verification policy document in containers-policy.json format, " +
+					"an alternative to policy_file. Overrides provider configuration.",
+				ConflictsWith:    []string{"policy_file", "insecure_policy"},
+				ValidateDiagFunc: validatePolicyJSON,
+			},
+			"insecure_policy": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				Description:   "inspect the image without consulting any signature verification policy.",
+				ConflictsWith: []string{"policy_file", "policy_json"},
+			},
+			"decryption_keys": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:  true,
+				Sensitive: true,
+				Description: "fail unless these private keys can decrypt every encrypted layer of the image, each either a key file " +
+					"path optionally followed by `:pass=<passphrase>` or `:file=<passphrase file>`, or an inline PEM " +
+					"private key.",
+			},
+			"platform": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "platform of the image to inspect from a multiple image manifest as `os/architecture[/variant]`, " +
+					"e.g. `linux/arm64`, instead of the platform running Terraform.",
+				ValidateDiagFunc: validatePlatform,
+			},
 			"retries": {
 				Type:     schema.TypeInt,
 				Optional: true,
@@ -165,12 +212,17 @@ func dataSourceSkopeo2InspectRead(ctx context.Context, d *schema.ResourceData, m
 	if err != nil {
 		return append(diagnosticsOut, diag.FromErr(err)...)
 	}
+	if d.Get("anonymous").(bool) {
+		src.anonymous = true
+	}
 
 	src.loginRetriesRemaining = src.loginRetries + 1
 
 	for {
-		result, err := loginInspect(ctx, d, src)
-		if err != nil {
+		result, err := loginInspect(ctx, d, config, src, true)
+		if isPolicyRejection(err) {
+			return append(diagnosticsOut, diagFromErr(err)...)
+		} else if err != nil {
 			diagnosticsOut = append(diagnosticsOut, diag.FromErr(err)...)
 		} else if result != nil {
 			d.SetId(src.image)
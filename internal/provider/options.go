@@ -23,59 +23,133 @@ func getStringList(d *schema.ResourceData, key string, def []string) []string {
 	return stringList
 }
 
-func newCopyOptions(d *schema.ResourceData, reportWriter *providerlog.ProviderLogWriter, src, dst *somewhere) *skopeo.CopyOptions {
+func getIntList(d *schema.ResourceData, key string, def []int) []int {
+	at := d.Get(key)
+	if at == nil {
+		return def
+	}
+	atl := at.([]interface{})
+	intList := make([]int, 0, len(atl))
+	for _, t := range atl {
+		intList = append(intList, t.(int))
+	}
+	return intList
+}
+
+func newCopyOptions(d *schema.ResourceData, config *PConfig, reportWriter *providerlog.ProviderLogWriter, src, dst *somewhere,
+	secrets *signingSecrets) *skopeo.CopyOptions {
 	additionalTags := getStringList(d, "additional_tags", nil)
 	preserveDigests := d.Get("preserve_digests").(bool)
 
 	opts := &skopeo.CopyOptions{
 		ReportWriter:    reportWriter,
-		SrcImage:        newImageOptions(d, src),
-		DestImage:       newImageDestOptions(d, dst),
+		SrcImage:        newImageOptions(d, config, src),
+		DestImage:       newImageDestOptions(d, config, dst),
 		RetryOpts:       newRetryOptions(d),
 		AdditionalTags:  additionalTags,
 		PreserveDigests: preserveDigests,
 		All:             d.Get("copy_all_images").(bool),
+		Platforms:       getStringList(d, "platforms", nil),
+		Format:          d.Get("manifest_format").(string),
+
+		RemoveSignatures: d.Get("signatures").(string) == signaturesRemove,
+
+		ForceCompressionFormat: d.Get("force_compression").(bool),
+
+		SignByFingerprint: d.Get("sign_by_fingerprint").(string),
+		SignPassphrase:    secrets.gpgPassphrase,
+		SignGPGHome:       d.Get("sign_gpg_home").(string),
+
+		SignBySigstorePrivateKeyFile:     d.Get("sign_by_sigstore_private_key").(string),
+		SignBySigstorePrivateKey:         []byte(d.Get("sign_sigstore_private_key_pem").(string)),
+		SignSigstorePrivateKeyPassphrase: []byte(secrets.sigstorePassphrase),
+		SignIdentity:                     d.Get("sign_identity").(string),
+
+		EncryptLayer:   getIntList(d, "encrypt_layers", nil),
+		EncryptionKeys: getStringList(d, "encryption_keys", nil),
+		DecryptionKeys: getStringList(d, "decryption_keys", nil),
 	}
 	return opts
 }
 
-func newDeleteOptions(d *schema.ResourceData, dst *somewhere) *skopeoPkg.DeleteOptions {
+func newDeleteOptions(d *schema.ResourceData, config *PConfig, dst *somewhere) *skopeoPkg.DeleteOptions {
 	opts := &skopeoPkg.DeleteOptions{
-		Image:     newImageDestOptions(d, dst).ImageOptions,
+		Image:     newImageDestOptions(d, config, dst).ImageOptions,
 		RetryOpts: newRetryOptions(d),
 	}
 	return opts
 }
 
-func newGlobalOptions() *skopeoPkg.GlobalOptions {
-	opts := &skopeoPkg.GlobalOptions{}
+func newGlobalOptions(d *schema.ResourceData, config *PConfig) *skopeoPkg.GlobalOptions {
+	opts := &skopeoPkg.GlobalOptions{
+		PolicyPath:     config.policyPath,
+		PolicyJSON:     config.policyJSON,
+		InsecurePolicy: d.Get("insecure_policy").(bool),
+
+		RegistriesDirPath:  config.registriesDirPath,
+		RegistriesConfPath: config.registriesConfPath,
+		RejectShortNames:   config.rejectShortNames,
+		TmpDir:             config.temporaryDirectory,
+	}
+	if tmpDir, ok := d.GetOk("temporary_directory"); ok {
+		opts.TmpDir = tmpDir.(string)
+	}
+	// A policy given to the resource replaces the provider policy rather than merging with it
+	if policyPath, ok := d.GetOk("policy_file"); ok {
+		opts.PolicyPath = policyPath.(string)
+		opts.PolicyJSON = ""
+	} else if policyJSON, ok := d.GetOk("policy_json"); ok {
+		opts.PolicyPath = ""
+		opts.PolicyJSON = policyJSON.(string)
+	}
+	if attr, ok := d.GetOk("platform"); ok {
+		// Validated when planned
+		platform, _ := skopeo.ParsePlatform(attr.(string))
+		opts.OverrideOS = platform.OS
+		opts.OverrideArch = platform.Architecture
+		opts.OverrideVariant = platform.Variant
+	}
 	return opts
 }
 
-func newImageDestOptions(d *schema.ResourceData, sw *somewhere) *skopeoPkg.ImageDestOptions {
+func newImageDestOptions(d *schema.ResourceData, config *PConfig, sw *somewhere) *skopeoPkg.ImageDestOptions {
 	opts := &skopeoPkg.ImageDestOptions{
-		ImageOptions: newImageOptions(d, sw),
+		ImageOptions:      newImageOptions(d, config, sw),
+		CompressionFormat: d.Get("compression_format").(string),
+	}
+	if level, ok := d.GetOk("compression_level"); ok {
+		compressionLevel := level.(int)
+		opts.CompressionLevel = &compressionLevel
 	}
 	return opts
 }
 
-func newImageOptions(d *schema.ResourceData, sw *somewhere) *skopeoPkg.ImageOptions {
+func newImageOptions(d *schema.ResourceData, config *PConfig, sw *somewhere) *skopeoPkg.ImageOptions {
 	opts := &skopeoPkg.ImageOptions{
 		DockerImageOptions: skopeoPkg.DockerImageOptions{
-			Global:         newGlobalOptions(),
+			Global:         newGlobalOptions(d, config),
 			Shared:         newSharedImageOptions(),
 			Insecure:       d.Get("insecure").(bool),
 			AuthFilePath:   sw.registryAuthFile,
 			DockerCertPath: sw.certificateDirectory,
+			NoCreds:        sw.anonymous,
 		},
 	}
+	// Anonymous access also ignores any token inherited from the provider configuration
+	if !sw.anonymous {
+		opts.RegistryToken = sw.registryToken
+	}
 	return opts
 }
 
-func newInspectOptions(d *schema.ResourceData, sw *somewhere) *skopeo.InspectOptions {
+func newInspectOptions(d *schema.ResourceData, config *PConfig, sw *somewhere, verifyPolicy bool) *skopeo.InspectOptions {
+	image := newImageOptions(d, config, sw)
 	opts := &skopeo.InspectOptions{
-		Image:     newImageOptions(d, sw),
-		RetryOpts: newRetryOptions(d),
+		Image:        image,
+		RetryOpts:    newRetryOptions(d),
+		VerifyPolicy: verifyPolicy && skopeo.HasPolicy(image.Global),
+
+		DecryptionKeys: getStringList(d, "decryption_keys", nil),
 	}
 	return opts
 }
@@ -93,9 +167,9 @@ func newSharedImageOptions() *skopeoPkg.SharedImageOptions {
 	return opts
 }
 
-func newLoginOptions(d *schema.ResourceData, sw *somewhere, reportWriter *providerlog.ProviderLogWriter, password string) *skopeo.LoginOptions {
+func newLoginOptions(d *schema.ResourceData, config *PConfig, sw *somewhere, reportWriter *providerlog.ProviderLogWriter, password string) *skopeo.LoginOptions {
 	opts := &skopeo.LoginOptions{
-		Image:    newImageOptions(d, sw),
+		Image:    newImageOptions(d, config, sw),
 		Username: sw.loginUsername,
 		Password: password,
 		CertPath: sw.certificateDirectory,
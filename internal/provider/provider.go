@@ -3,6 +3,9 @@ package provider
 import (
 	"context"
 
+	"github.com/bsquare-corp/terraform-provider-skopeo2/internal/skopeo"
+	"github.com/containers/image/v5/signature"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -38,6 +41,46 @@ func New(version string) func() *schema.Provider {
 					Description: "Destination image access credentials",
 					Elem:        &schema.Resource{Schema: SomewhereSchema("destination", true)},
 				},
+				"policy_file": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "Path of a containers-policy.json signature verification policy which source images must " +
+						"satisfy. By default any image is accepted.",
+					ConflictsWith: []string{"policy_json"},
+				},
+				"policy_json": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "Signature verification policy document in containers-policy.json format, " +
+						"an alternative to policy_file.",
+					ConflictsWith:    []string{"policy_file"},
+					ValidateDiagFunc: validatePolicyJSON,
+				},
+				"registries_conf": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "Path of a registries.conf file, or its TOML content, configuring registry mirrors, " +
+						"blocked registries and unqualified-search settings. By default the system configuration is used.",
+				},
+				"reject_short_names": {
+					Type:     schema.TypeBool,
+					Optional: true,
+					Default:  false,
+					Description: "fail rather than copy or inspect docker: images whose name does not include a registry, " +
+						"e.g. `docker://alpine`, instead of resolving them to docker.io.",
+				},
+				"registries_d_path": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "Path of a registries.d directory configuring where signatures are stored, such as " +
+						"lookaside web servers or staging directories. By default the system configuration is used.",
+				},
+				"temporary_directory": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "Directory for the large temporary files written when copying to or from archives and " +
+						"the docker daemon. By default the system temporary directory is used.",
+				},
 			},
 		}
 
@@ -50,6 +93,29 @@ func New(version string) func() *schema.Provider {
 type PConfig struct {
 	// Source/dest params can be overridden in the copy resource
 	source, destination *somewhere
+	// Signature verification policy, can be overridden in the copy resource and inspect data source
+	policyPath, policyJSON string
+	// registries.d directory locating signature storage
+	registriesDirPath string
+	// registries.conf file and short name handling
+	registriesConfPath string
+	rejectShortNames   bool
+	// Directory for big temporary files, can be overridden in the copy resource
+	temporaryDirectory string
+}
+
+func validatePolicyJSON(v interface{}, p cty.Path) diag.Diagnostics {
+	if _, err := signature.NewPolicyFromBytes([]byte(v.(string))); err != nil {
+		return diag.Errorf("Invalid signature verification policy: %v", err)
+	}
+	return nil
+}
+
+func validatePlatform(v interface{}, p cty.Path) diag.Diagnostics {
+	if _, err := skopeo.ParsePlatform(v.(string)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
 }
 
 func configure(version string, p *schema.Provider) func(context.Context, *schema.ResourceData) (any, diag.Diagnostics) {
@@ -64,9 +130,23 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			return nil, diag.FromErr(err)
 		}
 
+		registriesConfPath := ""
+		if registriesConf, ok := d.GetOk("registries_conf"); ok {
+			if registriesConfPath, err = getRegistriesConfPath(registriesConf.(string)); err != nil {
+				return nil, diag.FromErr(err)
+			}
+		}
+
 		return &PConfig{
 			source:      src,
 			destination: dst,
+			policyPath:  d.Get("policy_file").(string),
+			policyJSON:  d.Get("policy_json").(string),
+
+			registriesDirPath:  d.Get("registries_d_path").(string),
+			registriesConfPath: registriesConfPath,
+			rejectShortNames:   d.Get("reject_short_names").(bool),
+			temporaryDirectory: d.Get("temporary_directory").(string),
 		}, nil
 	}
 }
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+)
+
+// isInlineRegistriesConf reports whether registries_conf holds TOML rather than a path
+func isInlineRegistriesConf(value string) bool {
+	return strings.ContainsAny(value, "\n=[")
+}
+
+// getRegistriesConfPath returns the path of the registries.conf file configured by value, writing inline TOML
+// to a file as the registry configuration can only be loaded from files. The file is named after its content so
+// that every provider instance with the same configuration shares it, and the configuration is validated.
+func getRegistriesConfPath(value string) (string, error) {
+	path := value
+	if isInlineRegistriesConf(value) {
+		sum := sha256.Sum256([]byte(value))
+		path = filepath.Join(os.TempDir(), "skopeo2-registries-"+hex.EncodeToString(sum[:8])+".conf")
+		if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+			return "", err
+		}
+	}
+	if _, err := sysregistriesv2.TryUpdatingCache(&types.SystemContext{SystemRegistriesConfPath: path}); err != nil {
+		return "", fmt.Errorf("invalid registries_conf: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetRegistriesConfPath(t *testing.T) {
+	inline := `unqualified-search-registries = ["registry.example.com"]
+
+[[registry]]
+location = "docker.io"
+blocked = true
+`
+	path, err := getRegistriesConfPath(inline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != inline {
+		t.Errorf("unexpected registries.conf content %q", contents)
+	}
+
+	// A path is used as is
+	confFile := filepath.Join(t.TempDir(), "registries.conf")
+	if err := os.WriteFile(confFile, []byte(inline), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if path, err = getRegistriesConfPath(confFile); err != nil || path != confFile {
+		t.Errorf("unexpected path %s, err %v", path, err)
+	}
+
+	if _, err = getRegistriesConfPath("[[registry]]\nlocation = \"docker.io\"\nmirror = 1\n"); err == nil ||
+		!strings.Contains(err.Error(), "invalid registries_conf") {
+		t.Errorf("expected invalid configuration error, got %v", err)
+	}
+}
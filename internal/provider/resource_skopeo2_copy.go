@@ -12,6 +12,8 @@ import (
 	"github.com/bsquare-corp/terraform-provider-skopeo2/internal/providerlog"
 	"github.com/bsquare-corp/terraform-provider-skopeo2/internal/skopeo"
 	skopeoPkg "github.com/bsquare-corp/terraform-provider-skopeo2/pkg/skopeo"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/compression"
 	"github.com/containers/image/v5/storage"
 	"github.com/containers/image/v5/transports"
 	"github.com/containers/image/v5/transports/alltransports"
@@ -22,6 +24,11 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+const (
+	signaturesPreserve = "preserve"
+	signaturesRemove   = "remove"
+)
+
 var (
 	imageDescriptionTemplate = fmt.Sprintf(`specified as a "transport":"details" format.
 
@@ -39,6 +46,19 @@ func resourceSkopeo2Copy() *schema.Resource {
 		return nil
 	}
 
+	validateEncryptionKeyFunc := func(v interface{}, p cty.Path) diag.Diagnostics {
+		key := v.(string)
+		protocol, value, found := strings.Cut(key, ":")
+		if !found || value == "" {
+			return diag.Errorf("Invalid encryption key %s: expected <protocol>:<value>", key)
+		}
+		switch protocol {
+		case "jwe", "pkcs7", "pgp", "pkcs11", "provider":
+			return nil
+		}
+		return diag.Errorf("Invalid encryption key %s: unsupported protocol %s", key, protocol)
+	}
+
 	return &schema.Resource{
 		// This description is used by the documentation generator and the language server.
 		Description: "Copy resource in the Terraform provider skopeo2.",
@@ -147,6 +167,200 @@ func resourceSkopeo2Copy() *schema.Resource {
 				Default:     false,
 				Description: "allow access to non-TLS insecure repositories.",
 			},
+			"policy_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Path of a containers-policy.json signature verification policy which the source image " +
+					"must satisfy. Overrides provider configuration.",
+				ConflictsWith: []string{"policy_json", "insecure_policy"},
+			},
+			"policy_json": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Signature verification policy document in containers-policy.json format, " +
+					"an alternative to policy_file. Overrides provider configuration.",
+				ConflictsWith:    []string{"policy_file", "insecure_policy"},
+				ValidateDiagFunc: validatePolicyJSON,
+			},
+			"insecure_policy": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				Description:   "accept the source image without consulting any signature verification policy.",
+				ConflictsWith: []string{"policy_file", "policy_json"},
+			},
+			"signatures": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  signaturesPreserve,
+				Description: "what to do with the source image signatures, `preserve` copies them to the destination " +
+					"and `remove` drops them, e.g. for registries which reject signatures.",
+				ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
+					switch v.(string) {
+					case signaturesPreserve, signaturesRemove:
+						return nil
+					}
+					return diag.Errorf("Invalid signatures mode %s: expected %s or %s", v.(string), signaturesPreserve,
+						signaturesRemove)
+				},
+			},
+			"sign_by_fingerprint": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "sign the destination image using a GPG key with the specified fingerprint.",
+			},
+			"sign_gpg_home": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "GPG home directory holding the sign_by_fingerprint key, default is GNUPGHOME or ~/.gnupg.",
+				RequiredWith: []string{"sign_by_fingerprint"},
+			},
+			"sign_passphrase_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Path of a file whose first line is the passphrase of the sign_by_fingerprint key. " +
+					"The key must not need a passphrase if this and sign_passphrase_script are omitted.",
+				ConflictsWith: []string{"sign_passphrase_script"},
+				RequiredWith:  []string{"sign_by_fingerprint"},
+			},
+			"sign_passphrase_script": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Script to be executed to obtain the passphrase of the sign_by_fingerprint key. " +
+					"Passphrase returned on STDOUT by the script. The script is run using the destination " +
+					"login_script_interpreter, login_environment, working_directory and timeout.",
+				ConflictsWith: []string{"sign_passphrase_file"},
+				RequiredWith:  []string{"sign_by_fingerprint"},
+			},
+			"sign_by_sigstore_private_key": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "sign the destination image using the sigstore private key file at the specified path.",
+				ConflictsWith: []string{"sign_sigstore_private_key_pem"},
+			},
+			"sign_sigstore_private_key_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				Description:   "sign the destination image using the specified PEM sigstore private key.",
+				ConflictsWith: []string{"sign_by_sigstore_private_key"},
+			},
+			"sign_sigstore_passphrase_file": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Path of a file whose first line is the passphrase of the sigstore private key. " +
+					"The key must not need a passphrase if this and sign_sigstore_passphrase_script are omitted.",
+				ConflictsWith: []string{"sign_sigstore_passphrase_script"},
+			},
+			"sign_sigstore_passphrase_script": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "Script to be executed to obtain the passphrase of the sigstore private key. " +
+					"Passphrase returned on STDOUT by the script. The script is run using the destination " +
+					"login_script_interpreter, login_environment, working_directory and timeout.",
+				ConflictsWith: []string{"sign_sigstore_passphrase_file"},
+			},
+			"sign_identity": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "docker reference identity to record in the signatures added to the destination image, " +
+					"default is the destination image reference.",
+				ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
+					if _, err := reference.ParseNamed(v.(string)); err != nil {
+						return diag.Errorf("Invalid signature identity %s: %v", v.(string), err)
+					}
+					return nil
+				},
+			},
+			"sigstore_attachment": {
+				Type:     schema.TypeString,
+				Computed: true,
+				Description: "image holding the sigstore signatures added to a registry destination, " +
+					"deleted with the destination image unless keep_image is set.",
+			},
+			"signed_identity": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "docker reference identity recorded in the signatures added to the destination image.",
+			},
+			"manifest_format": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "convert the destination image manifest to this format, one of `oci`, `v2s1` or `v2s2`. " +
+					"Cannot be used with preserve_digests as conversion changes the digest.",
+				ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
+					if _, err := skopeoPkg.ParseManifestFormat(v.(string)); err != nil {
+						return diag.FromErr(err)
+					}
+					return nil
+				},
+				ConflictsWith: []string{"preserve_digests"},
+			},
+			"compression_format": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "compress the destination image layers using this format, one of `gzip`, `zstd` or " +
+					"`zstd:chunked`. Layers already compressed are only recompressed if force_compression is set.",
+				ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
+					if _, err := compression.AlgorithmByName(v.(string)); err != nil {
+						return diag.FromErr(err)
+					}
+					return nil
+				},
+			},
+			"compression_level": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "compression level to use with compression_format, the format default if omitted.",
+				RequiredWith: []string{"compression_format"},
+			},
+			"force_compression": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "recompress layers which are compressed in a format other than compression_format. " +
+					"Cannot be used with preserve_digests as recompression changes the digest.",
+				RequiredWith:  []string{"compression_format"},
+				ConflictsWith: []string{"preserve_digests"},
+			},
+			"encryption_keys": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validateEncryptionKeyFunc,
+				},
+				Optional: true,
+				Description: "encrypt the destination image layers for these recipients, given as `jwe:<public key file>`, " +
+					"`pkcs7:<x509 certificate file>` or `pgp:<GPG recipient>`. Cannot be used with preserve_digests as " +
+					"encryption changes the digest.",
+				ConflictsWith: []string{"preserve_digests", "decryption_keys"},
+			},
+			"encrypt_layers": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+				Optional: true,
+				Description: "indexes of the layers to encrypt, negative indexes count back from the last layer. " +
+					"All layers are encrypted if omitted.",
+				RequiredWith: []string{"encryption_keys"},
+			},
+			"decryption_keys": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:  true,
+				Sensitive: true,
+				Description: "decrypt the source image layers using these private keys, each either a key file path optionally " +
+					"followed by `:pass=<passphrase>` or `:file=<passphrase file>`, or an inline PEM private key.",
+				ConflictsWith: []string{"encryption_keys"},
+			},
+			"encrypted": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "the destination image layers were encrypted by the copy.",
+			},
 			"copy_all_images": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -154,6 +368,39 @@ func resourceSkopeo2Copy() *schema.Resource {
 				Description: "indicates that the caller expects to copy all images from a multiple image manifest, " +
 					"otherwise only one image matching the system arch/platform is copied",
 			},
+			"temporary_directory": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "directory for the large temporary files written when copying to or from archives and " +
+					"the docker daemon, instead of the provider temporary_directory.",
+			},
+			"platform": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Description: "platform of the image to copy from a multiple image manifest as `os/architecture[/variant]`, " +
+					"e.g. `linux/arm64`, instead of the platform running Terraform. Ignored when copy_all_images is set.",
+				ValidateDiagFunc: validatePlatform,
+			},
+			"platforms": {
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validatePlatform,
+				},
+				Optional: true,
+				Description: "platforms of the images to copy from a multiple image manifest as " +
+					"`os/architecture[/variant]`, e.g. `[\"linux/amd64\", \"linux/arm64\"]`. The copy fails if any " +
+					"platform is missing from the source.",
+				ConflictsWith: []string{"copy_all_images", "platform"},
+			},
+			"platform_digests": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Description: "destination digest of the image copied for each of the platforms.",
+			},
 			"docker_digest": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -222,6 +469,11 @@ func resourceSkopeo2CopyCreate(ctx context.Context, d *schema.ResourceData, meta
 		return diag.FromErr(err)
 	}
 
+	secrets, err := getSigningSecrets(ctx, d, dst)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	reportWriter := providerlog.NewProviderLogWriter(
 		log.Default().Writer(),
 	)
@@ -230,10 +482,10 @@ func resourceSkopeo2CopyCreate(ctx context.Context, d *schema.ResourceData, meta
 	src.loginRetriesRemaining = src.loginRetries + 1
 	dst.loginRetriesRemaining = dst.loginRetries + 1
 	for {
-		result, err := src.WithEndpointLogin(ctx, d, false, func(locked bool) (any, error) {
+		result, err := src.WithEndpointLogin(ctx, d, config, false, func(locked bool) (any, error) {
 			// inspect the source image and obtain its digest
 			tflog.Debug(ctx, "Inspecting Source", map[string]any{"image": src.image})
-			inspectResult, err := skopeo.Inspect(ctx, src.image, newInspectOptions(d, src))
+			inspectResult, err := skopeo.Inspect(ctx, src.image, newInspectOptions(d, config, src, false))
 			if err != nil {
 				tflog.Info(ctx, "Source Inspection failed",
 					map[string]any{"image": src.image, "err": err.Error(), "missing": isMissingInspectError(err)})
@@ -245,9 +497,9 @@ func resourceSkopeo2CopyCreate(ctx context.Context, d *schema.ResourceData, meta
 			}
 
 			// return the results of the copy to the dest image
-			return dst.WithEndpointLogin(ctx, d, locked, func(_ bool) (any, error) {
+			return dst.WithEndpointLogin(ctx, d, config, locked, func(_ bool) (any, error) {
 				tflog.Debug(ctx, "Copying", map[string]any{"src-image": src.image, "image": dst.image})
-				result, err := skopeo.Copy(ctx, src.image, dst.image, newCopyOptions(d, reportWriter, src, dst))
+				result, err := skopeo.Copy(ctx, src.image, dst.image, newCopyOptions(d, config, reportWriter, src, dst, secrets))
 				if err != nil {
 					tflog.Info(ctx, "Copy failed", map[string]any{"src-image": src.image, "image": dst.image, "err": err})
 					return nil, err
@@ -259,15 +511,33 @@ func resourceSkopeo2CopyCreate(ctx context.Context, d *schema.ResourceData, meta
 
 		if err == nil {
 			d.SetId(dst.image)
-			digest := result.(*skopeo.CopyResult).Digest
-			tflog.Info(ctx, "Copied", map[string]any{"src-image": src.image, "image": dst.image, "digest": digest})
-			return diag.FromErr(d.Set("docker_digest", digest))
+			copyResult := result.(*skopeo.CopyResult)
+			tflog.Info(ctx, "Copied", map[string]any{"src-image": src.image, "image": dst.image,
+				"digest": copyResult.Digest})
+			if err := d.Set("signed_identity", copyResult.SignedIdentity); err != nil {
+				return diag.FromErr(err)
+			}
+			if err := d.Set("sigstore_attachment", copyResult.SigstoreAttachment); err != nil {
+				return diag.FromErr(err)
+			}
+			if err := d.Set("encrypted", copyResult.Encrypted); err != nil {
+				return diag.FromErr(err)
+			}
+			if err := d.Set("platform_digests", copyResult.PlatformDigests); err != nil {
+				return diag.FromErr(err)
+			}
+			return diag.FromErr(d.Set("docker_digest", copyResult.Digest))
+		}
+
+		if isPolicyRejection(err) {
+			// Logging in again will not change the verdict of the trust policy
+			return diagFromErr(err)
 		}
 
 		tflog.Info(ctx, "Retries remaining", map[string]any{"source_count": src.loginRetriesRemaining,
 			"destination_count": dst.loginRetriesRemaining})
 		if src.loginRetriesRemaining <= 0 || dst.loginRetriesRemaining <= 0 {
-			return diag.FromErr(err)
+			return diagFromErr(err)
 		}
 	}
 }
@@ -289,10 +559,50 @@ func isMissingInspectError(inspectErr error) bool {
 		strings.Contains(inspectErr.Error(), "name unknown")
 }
 
-func loginInspect(ctx context.Context, d *schema.ResourceData, sw *somewhere) (*skopeo.InspectOutput, error) {
-	result, err := sw.WithEndpointLogin(ctx, d, false, func(_ bool) (any, error) {
+// isMissingDeleteError examines the error from the delete call to determine if the reason
+// was because the image does not exist
+func isMissingDeleteError(deleteErr error) bool {
+	return isMissingInspectError(deleteErr) || strings.Contains(deleteErr.Error(), "Image may not exist")
+}
+
+// isPolicyRejection reports whether err is the signature trust policy refusing an image
+func isPolicyRejection(err error) bool {
+	var rejection *skopeo.PolicyRejectionError
+	return errors.As(err, &rejection)
+}
+
+// diagFromErr converts err to diagnostics, giving failures that are not access or transport problems
+// their own summary so they can be told apart.
+func diagFromErr(err error) diag.Diagnostics {
+	var rejection *skopeo.PolicyRejectionError
+	if errors.As(err, &rejection) {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Image rejected by signature trust policy",
+			Detail: fmt.Sprintf("%s was rejected by the %s requirement(s) of the %q policy scope: %v",
+				rejection.Image, strings.Join(rejection.Requirements, ", "), rejection.Scope, rejection.Err),
+		}}
+	}
+	var space *skopeo.InsufficientSpaceError
+	if errors.As(err, &space) {
+		detail := fmt.Sprintf("The image needs %d bytes but %s only has %d bytes available.", space.Required,
+			space.Dir, space.Available)
+		if space.Temporary {
+			detail += " Set temporary_directory to a directory with more space."
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Insufficient disk space to copy image",
+			Detail:   detail,
+		}}
+	}
+	return diag.FromErr(err)
+}
+
+func loginInspect(ctx context.Context, d *schema.ResourceData, config *PConfig, sw *somewhere, verifyPolicy bool) (*skopeo.InspectOutput, error) {
+	result, err := sw.WithEndpointLogin(ctx, d, config, false, func(_ bool) (any, error) {
 		tflog.Debug(ctx, "Inspecting", map[string]any{"image": sw.image})
-		result, err := skopeo.Inspect(ctx, sw.image, newInspectOptions(d, sw))
+		result, err := skopeo.Inspect(ctx, sw.image, newInspectOptions(d, config, sw, verifyPolicy))
 		if err != nil {
 			missing := isMissingInspectError(err)
 			tflog.Info(ctx, "Inspection failed", map[string]any{"image": sw.image, "err": err.Error(), "missing": missing})
@@ -321,7 +631,7 @@ func resourceSkopeo2CopyRead(ctx context.Context, d *schema.ResourceData, meta a
 	dst.loginRetriesRemaining = dst.loginRetries + 1
 
 	for {
-		result, err := loginInspect(ctx, d, dst)
+		result, err := loginInspect(ctx, d, config, dst, false)
 		if err != nil {
 			diagnosticsOut = append(diagnosticsOut, diag.FromErr(err)...)
 		}
@@ -356,7 +666,7 @@ func resourceSkopeo2CopyRead(ctx context.Context, d *schema.ResourceData, meta a
 	src.loginRetriesRemaining = src.loginRetries + 1
 
 	for {
-		result, err := loginInspect(ctx, d, src)
+		result, err := loginInspect(ctx, d, config, src, false)
 		if err != nil {
 			diagnosticsOut = append(diagnosticsOut, diag.FromErr(err)...)
 		}
@@ -410,9 +720,19 @@ func resourceSkopeo2CopyDelete(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	for {
-		_, err := dst.WithEndpointLogin(ctx, d, false, func(_ bool) (any, error) {
+		_, err := dst.WithEndpointLogin(ctx, d, config, false, func(_ bool) (any, error) {
+			// Registries keep sigstore signatures in a separate image which would outlive the signed image
+			if attachment, ok := d.GetOk("sigstore_attachment"); ok {
+				tflog.Debug(ctx, "Deleting signatures", map[string]any{"image": attachment})
+				err := skopeoPkg.Delete(ctx, attachment.(string), newDeleteOptions(d, config, dst))
+				if err != nil && !isMissingDeleteError(err) {
+					tflog.Info(ctx, "Delete signatures fail", map[string]any{"image": attachment, "err": err})
+					return nil, err
+				}
+			}
+
 			tflog.Debug(ctx, "Deleting", map[string]any{"image": dst.image})
-			err := skopeoPkg.Delete(ctx, dst.image, newDeleteOptions(d, dst))
+			err := skopeoPkg.Delete(ctx, dst.image, newDeleteOptions(d, config, dst))
 			if err != nil {
 				tflog.Info(ctx, "Delete fail", map[string]any{"image": dst.image, "err": err})
 				return nil, err
@@ -425,32 +745,61 @@ func resourceSkopeo2CopyDelete(ctx context.Context, d *schema.ResourceData, meta
 	}
 }
 
+// isTransformingCopy reports whether the copy rewrites the destination manifest, so that the destination digest
+// is expected to differ from the source digest
+func isTransformingCopy(d *schema.ResourceDiff) bool {
+	if encrypted, _ := d.GetOk("encrypted"); encrypted.(bool) {
+		return true
+	}
+	if recompressed, _ := d.GetOk("force_compression"); recompressed.(bool) {
+		return true
+	}
+	_, converted := d.GetOk("manifest_format")
+	return converted
+}
+
 func resourceSkopeo2CopyDiffFunc() schema.CustomizeDiffFunc {
-	return customdiff.ForceNewIf("docker_digest", func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) bool {
-		preserveDigests, _ := d.GetOk("preserve_digests")
-		if !preserveDigests.(bool) {
-			// If we are not preserving digests, we cannot determine if a new copy is needed as there
-			// is no guarantee that the dest will have the same digest as the source
-			// Default to not force create and therefore not copy
-			return false
-		}
-		destDigest, ok := d.GetOk("docker_digest")
-		if !ok {
-			// Do the copy if there is no docker_digest in state, which means it's a new resource
-			return true
-		}
-		sourceDigest, ok := d.GetOk("source_digest")
-		if !ok {
-			// Do the copy if there is no source_digest in state, which can happen on a provider update
-			// because previous providers didn't have this state variable
-			return true
-		}
+	return customdiff.All(
+		customdiff.IfValueChange("encryption_keys",
+			func(ctx context.Context, old, new, meta interface{}) bool {
+				oldKeys, _ := old.([]interface{})
+				newKeys, _ := new.([]interface{})
+				return len(oldKeys) != len(newKeys)
+			},
+			func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+				// Keep the plan in step with what the copy will record
+				return d.SetNew("encrypted", len(d.Get("encryption_keys").([]interface{})) > 0)
+			}),
+		customdiff.ForceNewIf("docker_digest", func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) bool {
+			preserveDigests, _ := d.GetOk("preserve_digests")
+			if !preserveDigests.(bool) {
+				// If we are not preserving digests, we cannot determine if a new copy is needed as there
+				// is no guarantee that the dest will have the same digest as the source
+				// Default to not force create and therefore not copy
+				return false
+			}
+			if isTransformingCopy(d) {
+				// The destination never has the source digest, comparing them would copy on every apply
+				return false
+			}
+			destDigest, ok := d.GetOk("docker_digest")
+			if !ok {
+				// Do the copy if there is no docker_digest in state, which means it's a new resource
+				return true
+			}
+			sourceDigest, ok := d.GetOk("source_digest")
+			if !ok {
+				// Do the copy if there is no source_digest in state, which can happen on a provider update
+				// because previous providers didn't have this state variable
+				return true
+			}
 
-		// Force new copy if the source and dest digests do not match
-		if sourceDigest.(string) != destDigest.(string) {
-			_ = d.SetNewComputed("docker_digest")
-			return true
-		}
-		return false
-	})
+			// Force new copy if the source and dest digests do not match
+			if sourceDigest.(string) != destDigest.(string) {
+				_ = d.SetNewComputed("docker_digest")
+				return true
+			}
+			return false
+		}),
+	)
 }
@@ -537,6 +537,10 @@ func TestAccResourceSkopeo2(t *testing.T) {
 				Config:      testAccCopyBadResourceFail(rName),
 				ExpectError: expectErrorRegExpr("Invalid image name"),
 			},
+			{
+				Config:      testAccCopyResourcePolicyRejected(rName),
+				ExpectError: expectErrorRegExpr("Image rejected by signature trust policy"),
+			},
 			{
 				PreConfig:   logoutAll(),
 				Config:      testAccCopyResourceLoginFail(rName),
@@ -655,6 +659,21 @@ resource "skopeo2_copy" "testimage_bad_resource_%s" {
 }`, name, name)
 }
 
+func testAccCopyResourcePolicyRejected(name string) string {
+	return fmt.Sprintf(`
+resource "skopeo2_copy" "testimage_policy_rejected_%s" {
+    source_image = "%s"
+    destination_image = "docker://127.0.0.1:9016/testimage-policy-rejected-%s"
+    policy_json = jsonencode({
+      default = [{ type = "insecureAcceptAnything" }]
+      transports = {
+        docker = { "127.0.0.1:9016" = [{ type = "reject" }] }
+      }
+    })
+    insecure = true
+}`, name, testSrcImage, name)
+}
+
 func testAccCopyResourceLoginFail(name string) string {
 	return fmt.Sprintf(`
 resource "skopeo2_copy" "testimage_login_fail_%s" {
@@ -903,3 +922,128 @@ resource "skopeo2_copy" "updating_testimage_copy_resource_` + rName + `" {
 		},
 	})
 }
+
+func TestResourceSkopeo2CopyDiffEncrypted(t *testing.T) {
+	r := resourceSkopeo2Copy()
+	config := terraform.NewResourceConfigRaw(map[string]any{
+		"source_image":      testSrcImage,
+		"destination_image": "docker://127.0.0.1:9016/encrypted",
+		"encryption_keys":   []any{"jwe:/keys/public.pem"},
+	})
+
+	// A new copy plans to record the encryption
+	diff, err := r.Diff(context.Background(), nil, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attr, ok := diff.Attributes["encrypted"]; !ok || attr.New != "true" {
+		t.Errorf("encrypted not planned as true: %v", attr)
+	}
+
+	// A refreshed encrypted destination always differs from the source, that must not plan another copy
+	state := &terraform.InstanceState{
+		ID: "docker://127.0.0.1:9016/encrypted",
+		Attributes: map[string]string{
+			"id":                  "docker://127.0.0.1:9016/encrypted",
+			"source_image":        testSrcImage,
+			"destination_image":   "docker://127.0.0.1:9016/encrypted",
+			"encryption_keys.#":   "1",
+			"encryption_keys.0":   "jwe:/keys/public.pem",
+			"encrypted":           "true",
+			"docker_digest":       "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+			"source_digest":       "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			"retries":             "0",
+			"retry_delay":         "0",
+			"keep_image":          "false",
+			"preserve_digests":    "false",
+			"insecure":            "false",
+			"insecure_policy":     "false",
+			"copy_all_images":     "false",
+			"force_compression":   "false",
+			"signatures":          "preserve",
+			"additional_tags.#":   "0",
+			"encrypt_layers.#":    "0",
+			"platform_digests.%":  "0",
+			"sigstore_attachment": "",
+			"signed_identity":     "",
+		},
+	}
+	diff, err = r.Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("unexpected diff for an encrypted destination: %v", diff.Attributes)
+	}
+}
+
+func TestResourceSkopeo2CopyValidate(t *testing.T) {
+	cases := map[string]struct {
+		raw   map[string]any
+		error string
+	}{
+		"manifest format": {
+			raw:   map[string]any{"manifest_format": "oci"},
+			error: "",
+		},
+		"unknown manifest format": {
+			raw:   map[string]any{"manifest_format": "v3"},
+			error: "unknown format",
+		},
+		"manifest format preserving digests": {
+			raw:   map[string]any{"manifest_format": "oci", "preserve_digests": true},
+			error: "conflicts with preserve_digests",
+		},
+		"compression": {
+			raw:   map[string]any{"compression_format": "zstd", "compression_level": 19, "force_compression": true},
+			error: "",
+		},
+		"unknown compression format": {
+			raw:   map[string]any{"compression_format": "lz4"},
+			error: "cannot find compressor",
+		},
+		"forced compression preserving digests": {
+			raw:   map[string]any{"compression_format": "zstd", "force_compression": true, "preserve_digests": true},
+			error: "conflicts with preserve_digests",
+		},
+		"platform": {
+			raw:   map[string]any{"platform": "linux/arm64/v8"},
+			error: "",
+		},
+		"platform without architecture": {
+			raw:   map[string]any{"platform": "linux"},
+			error: "invalid platform",
+		},
+		"remove signatures": {
+			raw:   map[string]any{"signatures": "remove"},
+			error: "",
+		},
+		"unknown signatures mode": {
+			raw:   map[string]any{"signatures": "strip"},
+			error: "Invalid signatures mode",
+		},
+		"compression level without format": {
+			raw:   map[string]any{"compression_level": 3},
+			error: "compression_format",
+		},
+	}
+
+	for name, c := range cases {
+		c.raw["source_image"] = testSrcImage
+		c.raw["destination_image"] = "docker://127.0.0.1:9016/validate"
+		diags := resourceSkopeo2Copy().Validate(terraform.NewResourceConfigRaw(c.raw))
+		if c.error == "" {
+			if diags.HasError() {
+				t.Errorf("%s: unexpected error %v", name, diags)
+			}
+			continue
+		}
+		found := false
+		for _, d := range diags {
+			found = found || regexp.MustCompile(c.error).MatchString(d.Summary+d.Detail)
+		}
+		if !found {
+			t.Errorf("%s: expected error matching %q, got %v", name, c.error, diags)
+		}
+	}
+}
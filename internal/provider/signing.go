@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// signingSecrets holds the passphrases of the signing keys, obtained once before the copy is attempted
+type signingSecrets struct {
+	gpgPassphrase      string
+	sigstorePassphrase string
+}
+
+// getPassphrase reads a passphrase from the file attribute fileKey, or runs the script attribute scriptKey using the
+// destination login script settings. An empty passphrase is returned if neither is set.
+func getPassphrase(ctx context.Context, d *schema.ResourceData, dst *somewhere, fileKey, scriptKey string) (string, error) {
+	if path, ok := d.GetOk(fileKey); ok {
+		contents, err := os.ReadFile(path.(string))
+		if err != nil {
+			return "", err
+		}
+		// Only the first line is used, matching skopeo --sign-passphrase-file
+		line, _, _ := strings.Cut(string(contents), "\n")
+		return strings.TrimSuffix(line, "\r"), nil
+	}
+	if script, ok := d.GetOk(scriptKey); ok {
+		tflog.Info(ctx, "Running script to obtain signing passphrase", map[string]any{"image": dst.image})
+		return dst.RunLoginPasswordScript(ctx, script.(string))
+	}
+	return "", nil
+}
+
+func isSigstoreSigning(d *schema.ResourceData) bool {
+	_, keyFile := d.GetOk("sign_by_sigstore_private_key")
+	_, keyPEM := d.GetOk("sign_sigstore_private_key_pem")
+	return keyFile || keyPEM
+}
+
+func getSigningSecrets(ctx context.Context, d *schema.ResourceData, dst *somewhere) (*signingSecrets, error) {
+	secrets := &signingSecrets{}
+	if _, ok := d.GetOk("sign_by_fingerprint"); ok {
+		var err error
+		if secrets.gpgPassphrase, err = getPassphrase(ctx, d, dst, "sign_passphrase_file",
+			"sign_passphrase_script"); err != nil {
+			return nil, err
+		}
+	}
+	if isSigstoreSigning(d) {
+		var err error
+		if secrets.sigstorePassphrase, err = getPassphrase(ctx, d, dst, "sign_sigstore_passphrase_file",
+			"sign_sigstore_passphrase_script"); err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestGetSigningSecrets(t *testing.T) {
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("file passphrase\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := &somewhere{
+		image:            "docker://127.0.0.1:9016/signed",
+		loginInterpreter: []string{"/bin/sh", "-c"},
+		workingDirectory: defaultWorkingDirectory,
+		cmdTimeout:       time.Duration(defaultTimeout) * time.Second,
+	}
+
+	cases := map[string]struct {
+		raw      map[string]any
+		expected string
+	}{
+		"file": {
+			raw:      map[string]any{"sign_by_fingerprint": "ABCD", "sign_passphrase_file": passphraseFile},
+			expected: "file passphrase",
+		},
+		"script": {
+			raw:      map[string]any{"sign_by_fingerprint": "ABCD", "sign_passphrase_script": "echo script passphrase"},
+			expected: "script passphrase",
+		},
+		"none": {
+			raw:      map[string]any{"sign_by_fingerprint": "ABCD"},
+			expected: "",
+		},
+		"not signing": {
+			raw:      map[string]any{"sign_passphrase_script": "exit 1"},
+			expected: "",
+		},
+	}
+
+	for name, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceSkopeo2Copy().Schema, c.raw)
+		secrets, err := getSigningSecrets(context.Background(), d, dst)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if secrets.gpgPassphrase != c.expected {
+			t.Errorf("%s: passphrase %q, expected %q", name, secrets.gpgPassphrase, c.expected)
+		}
+	}
+}
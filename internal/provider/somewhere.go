@@ -69,7 +69,24 @@ func SomewhereSchema(parent string, scriptOptions bool) map[string]*schema.Schem
 		Description: "Path of the authentication file. Use REGISTRY_AUTH_FILE environment variable to override. " +
 			"Default is ${XDG_RUNTIME_DIR}/containers/auth.json",
 	}
+	s["registry_token"] = &schema.Schema{
+		Type:      schema.TypeString,
+		Optional:  true,
+		Sensitive: true,
+		Description: "Bearer token used directly to access the registry, without logging in or writing the " +
+			"authentication file",
+		ConflictsWith: subResArray(parent, "login_username", "login_password"),
+	}
+	s["anonymous"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Description: "Access the registry anonymously, ignoring any credentials in the authentication file. " +
+			"No login is attempted",
+		ConflictsWith: subResArray(parent, "login_username", "login_password", "registry_token"),
+	}
 	if scriptOptions {
+		s["anonymous"].ConflictsWith = subResArray(parent, "login_username", "login_password",
+			"login_password_script", "login_script", "registry_token", "registry_token_script")
 		s["login_username"].ConflictsWith = subResArray(parent, "login_script")
 		s["login_password"].ConflictsWith = subResArray(parent, "login_script", "login_password_script")
 		s["login_password_script"] = &schema.Schema{
@@ -87,6 +104,16 @@ func SomewhereSchema(parent string, scriptOptions bool) map[string]*schema.Schem
 				" following skopeo operations, default " + defaultLoginScript,
 			ConflictsWith: subResArray(parent, "login_username", "login_password", "login_password_script"),
 		}
+		s["registry_token"].ConflictsWith = subResArray(parent, "login_username", "login_password",
+			"login_password_script", "login_script", "registry_token_script")
+		s["registry_token_script"] = &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Description: "Script to be executed to obtain the bearer token used directly to access the registry." +
+				" Token returned on STDOUT by the script. The script is run again to refresh the token if access fails.",
+			ConflictsWith: subResArray(parent, "login_username", "login_password", "login_password_script",
+				"login_script", "registry_token"),
+		}
 		s["login_retries"] = &schema.Schema{
 			Type:     schema.TypeInt,
 			Optional: true,
@@ -155,6 +182,10 @@ type somewhere struct {
 	certificateDirectory    string
 	hasRegistryAuthFile     bool
 	registryAuthFile        string
+	hasRegistryToken        bool
+	registryToken           string
+	registryTokenScript     string
+	anonymous               bool
 }
 
 // Overriding Update this _somewhere_ object with elements from the provider block _somewhere_ object where this
@@ -212,6 +243,16 @@ func (sw *somewhere) Overriding(other *somewhere) {
 		sw.registryAuthFile = other.registryAuthFile
 		sw.hasRegistryAuthFile = other.hasRegistryAuthFile
 	}
+
+	if !sw.hasRegistryToken {
+		sw.registryToken = other.registryToken
+		sw.registryTokenScript = other.registryTokenScript
+		sw.hasRegistryToken = other.hasRegistryToken
+	}
+
+	if !sw.anonymous {
+		sw.anonymous = other.anonymous
+	}
 }
 
 func (sw *somewhere) SetImage(image string) {
@@ -302,10 +343,38 @@ func GetSomewhereParams(d *schema.ResourceData, key string) (*somewhere, error)
 		sw.registryAuthFile = defaultRegistryAuthFile
 	}
 
+	if registryTokenScript, ok := getOkSubRes("registry_token_script"); ok {
+		sw.registryTokenScript = registryTokenScript.(string)
+		sw.hasRegistryToken = true
+	} else if registryToken, ok := getOkSubRes("registry_token"); ok {
+		sw.registryToken = registryToken.(string)
+		sw.hasRegistryToken = true
+	}
+
+	if anonymous, ok := getOkSubRes("anonymous"); ok {
+		sw.anonymous = anonymous.(bool)
+	}
+
 	return &sw, nil
 }
 
-func (sw *somewhere) WithEndpointLogin(ctx context.Context, d *schema.ResourceData, locked bool, op func(locked bool) (any, error)) (any, error) {
+func (sw *somewhere) WithEndpointLogin(ctx context.Context, d *schema.ResourceData, config *PConfig, locked bool, op func(locked bool) (any, error)) (any, error) {
+
+	//Anonymous access has no credentials to log in with
+	if sw.anonymous {
+		result, err := op(locked)
+		if err != nil {
+			sw.loginRetriesRemaining--
+		}
+		return result, err
+	}
+
+	//A token script must have supplied a token before the operation can use it
+	if sw.registryTokenScript != "" && sw.registryToken == "" {
+		if err := sw.runRegistryTokenScript(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	//Try the operation without logging in first, as the credentials may already be in place
 	result, err := op(locked)
@@ -316,7 +385,7 @@ func (sw *somewhere) WithEndpointLogin(ctx context.Context, d *schema.ResourceDa
 	sw.loginRetriesRemaining--
 
 	//Return error without attempting login if no login command is provided
-	if sw.loginScript == "true" && sw.unPwLogin == false {
+	if sw.loginScript == "true" && sw.unPwLogin == false && sw.registryTokenScript == "" {
 		return nil, err
 	}
 
@@ -338,7 +407,7 @@ func (sw *somewhere) WithEndpointLogin(ctx context.Context, d *schema.ResourceDa
 	}
 
 	//Didn't succeed so login
-	err = sw.DoLogin(ctx, d)
+	err = sw.DoLogin(ctx, d, config)
 	if err != nil {
 		return nil, err
 	}
@@ -347,8 +416,12 @@ func (sw *somewhere) WithEndpointLogin(ctx context.Context, d *schema.ResourceDa
 	return op(true)
 }
 
-func (sw *somewhere) DoLogin(ctx context.Context, d *schema.ResourceData) error {
+func (sw *somewhere) DoLogin(ctx context.Context, d *schema.ResourceData, config *PConfig) error {
 	var err error
+	if sw.registryTokenScript != "" {
+		// The token may have expired, obtain a new one
+		return sw.runRegistryTokenScript(ctx)
+	}
 	if sw.unPwLogin {
 		var password = sw.loginPassword
 		if sw.pwScript {
@@ -360,14 +433,24 @@ func (sw *somewhere) DoLogin(ctx context.Context, d *schema.ResourceData) error
 		}
 		tflog.Info(ctx, "Login using username and password", map[string]any{"image": sw.image,
 			"username": sw.loginUsername})
-		return sw.doUnPwLogin(ctx, password, d)
+		return sw.doUnPwLogin(ctx, password, d, config)
 	}
 	tflog.Info(ctx, "Login using script", map[string]any{"image": sw.image})
 	_, err = sw.RunLoginPasswordScript(ctx, sw.loginScript)
 	return err
 }
 
-func (sw *somewhere) doUnPwLogin(ctx context.Context, password string, d *schema.ResourceData) error {
+func (sw *somewhere) runRegistryTokenScript(ctx context.Context) error {
+	tflog.Info(ctx, "Running script to obtain registry token", map[string]any{"image": sw.image})
+	token, err := sw.RunLoginPasswordScript(ctx, sw.registryTokenScript)
+	if err != nil {
+		return err
+	}
+	sw.registryToken = strings.TrimSpace(token)
+	return nil
+}
+
+func (sw *somewhere) doUnPwLogin(ctx context.Context, password string, d *schema.ResourceData, config *PConfig) error {
 
 	var err error
 
@@ -377,7 +460,7 @@ func (sw *somewhere) doUnPwLogin(ctx context.Context, password string, d *schema
 	defer logWriter.Close()
 
 	tflog.Debug(ctx, "Logging in", map[string]any{"image": sw.image, "user": sw.loginUsername})
-	err = skopeo.Login(ctx, sw.image, newLoginOptions(d, sw, logWriter, password))
+	err = skopeo.Login(ctx, sw.image, newLoginOptions(d, config, sw, logWriter, password))
 
 	if err != nil {
 		tflog.Info(ctx, "Login fail", map[string]any{"image": sw.image, "user": sw.loginUsername, "err": err})
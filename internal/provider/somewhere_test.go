@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containers/image/v5/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRegistryTokenScript(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("expired\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceSkopeo2Copy().Schema, map[string]any{})
+	config := &PConfig{}
+	sw := &somewhere{
+		image:               "docker://127.0.0.1:9016/token",
+		loginScript:         defaultLoginScript,
+		loginInterpreter:    []string{"/bin/sh", "-c"},
+		workingDirectory:    dir,
+		cmdTimeout:          time.Duration(defaultTimeout) * time.Second,
+		hasRegistryToken:    true,
+		registryTokenScript: "cat token",
+	}
+
+	var tokens []string
+	_, err := sw.WithEndpointLogin(context.Background(), d, config, false, func(_ bool) (any, error) {
+		token := newImageOptions(d, config, sw).RegistryToken
+		tokens = append(tokens, token)
+		if token != "fresh" {
+			// The registry rejects the token, the script will issue a new one
+			if err := os.WriteFile(filepath.Join(dir, "token"), []byte("fresh\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			return nil, errors.New("unauthorized")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0] != "expired" || tokens[1] != "fresh" {
+		t.Errorf("unexpected tokens used %v", tokens)
+	}
+
+	// A static token is used as is and never refreshed
+	sw = &somewhere{image: sw.image, loginScript: defaultLoginScript, hasRegistryToken: true, registryToken: "static"}
+	_, err = sw.WithEndpointLogin(context.Background(), d, config, false, func(_ bool) (any, error) {
+		if token := newImageOptions(d, config, sw).RegistryToken; token != "static" {
+			t.Errorf("unexpected static token %q", token)
+		}
+		return nil, errors.New("denied")
+	})
+	if err == nil || err.Error() != "denied" {
+		t.Errorf("expected the access failure, got %v", err)
+	}
+}
+
+func TestAnonymousAccess(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceSkopeo2Copy().Schema, map[string]any{})
+	config := &PConfig{}
+	provider := &somewhere{
+		loginScript:      "exit 1",
+		hasLoginScript:   true,
+		loginInterpreter: []string{"/bin/sh", "-c"},
+		cmdTimeout:       time.Duration(defaultTimeout) * time.Second,
+		hasRegistryToken: true,
+		registryToken:    "provider token",
+	}
+	sw := &somewhere{image: "docker://127.0.0.1:9016/anonymous", anonymous: true}
+	sw.Overriding(provider)
+
+	opts := newImageOptions(d, config, sw)
+	if !opts.NoCreds || opts.RegistryToken != "" {
+		t.Errorf("credentials used for anonymous access: no creds %v, token %q", opts.NoCreds, opts.RegistryToken)
+	}
+	sysCtx, err := opts.NewSystemContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sysCtx.DockerAuthConfig == nil || *sysCtx.DockerAuthConfig != (types.DockerAuthConfig{}) {
+		t.Errorf("expected empty credentials, got %v", sysCtx.DockerAuthConfig)
+	}
+
+	// The failing login script would replace the access error if a login were attempted
+	sw.loginRetriesRemaining = 1
+	_, err = sw.WithEndpointLogin(context.Background(), d, config, false, func(_ bool) (any, error) {
+		return nil, errors.New("denied")
+	})
+	if err == nil || err.Error() != "denied" {
+		t.Errorf("expected the access failure, got %v", err)
+	}
+	if sw.loginRetriesRemaining != 0 {
+		t.Errorf("failure not counted against the retries, %d remaining", sw.loginRetriesRemaining)
+	}
+}
@@ -1,17 +1,129 @@
 package skopeo
 
-import "github.com/containers/image/v5/signature"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 
-func getPolicyContext(insecurePolicy bool, policyPath string) (*signature.PolicyContext, error) {
+	skopeoPkg "github.com/bsquare-corp/terraform-provider-skopeo2/pkg/skopeo"
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/transports"
+	"github.com/containers/image/v5/types"
+)
+
+// PolicyRejectionError is returned when the signature trust policy does not allow an image to be used.
+type PolicyRejectionError struct {
+	Image        string   // The image which was rejected
+	Scope        string   // The policy scope which supplied the requirements, "default" for the policy default
+	Requirements []string // The types of the requirements which applied to the image
+	Err          error    // The reason given by the policy evaluation
+}
+
+func (e *PolicyRejectionError) Error() string {
+	return fmt.Sprintf("image %s rejected by trust policy scope %q requirements %v: %v", e.Image, e.Scope,
+		e.Requirements, e.Err)
+}
+
+func (e *PolicyRejectionError) Unwrap() error {
+	return e.Err
+}
+
+// HasPolicy reports whether opts configure a signature verification policy rather than the default of
+// accepting anything.
+func HasPolicy(opts *skopeoPkg.GlobalOptions) bool {
+	return !opts.InsecurePolicy && (opts.PolicyPath != "" || opts.PolicyJSON != "")
+}
+
+// getPolicyContext returns the policy context for opts along with the policy it evaluates, which is needed to
+// explain any rejection.
+func getPolicyContext(opts *skopeoPkg.GlobalOptions) (*signature.PolicyContext, *signature.Policy, error) {
 	var policy *signature.Policy // This could be cached across calls in opts.
 	var err error
-	if insecurePolicy || policyPath == "" {
+	if !HasPolicy(opts) {
 		policy = &signature.Policy{Default: []signature.PolicyRequirement{signature.NewPRInsecureAcceptAnything()}}
+	} else if opts.PolicyJSON != "" {
+		policy, err = signature.NewPolicyFromBytes([]byte(opts.PolicyJSON))
 	} else {
-		policy, err = signature.NewPolicyFromFile(policyPath)
+		policy, err = signature.NewPolicyFromFile(opts.PolicyPath)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return policyContext, policy, nil
+}
+
+// verifyPolicy evaluates the signature trust policy in opts against img.
+func verifyPolicy(ctx context.Context, opts *skopeoPkg.GlobalOptions, img types.UnparsedImage) error {
+	policyContext, policy, err := getPolicyContext(opts)
+	if err != nil {
+		return fmt.Errorf("Error loading trust policy: %v", err)
+	}
+	defer policyContext.Destroy()
+
+	if _, err := policyContext.IsRunningImageAllowed(ctx, img); err != nil {
+		return policyRejection(policy, img.Reference(), err)
+	}
+	return nil
+}
+
+// policyRejection converts a policy evaluation failure for ref into a *PolicyRejectionError,
+// other errors are returned unchanged.
+func policyRejection(policy *signature.Policy, ref types.ImageReference, err error) error {
+	var reqErr signature.PolicyRequirementError
+	if !errors.As(err, &reqErr) {
+		return err
+	}
+
+	scope, reqs := policyRequirementsFor(policy, ref)
+	reqTypes := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		reqTypes = append(reqTypes, policyRequirementType(req))
+	}
+
+	return &PolicyRejectionError{
+		Image:        transports.ImageName(ref),
+		Scope:        scope,
+		Requirements: reqTypes,
+		Err:          err,
+	}
+}
+
+// policyRequirementsFor mirrors the scope selection of signature.PolicyContext so that a rejection can be
+// reported against the policy section which caused it.
+func policyRequirementsFor(policy *signature.Policy, ref types.ImageReference) (string, signature.PolicyRequirements) {
+	transportName := ref.Transport().Name()
+	if transportScopes, ok := policy.Transports[transportName]; ok {
+		identity := ref.PolicyConfigurationIdentity()
+		if req, ok := transportScopes[identity]; ok {
+			return transportName + ":" + identity, req
+		}
+
+		for _, name := range ref.PolicyConfigurationNamespaces() {
+			if req, ok := transportScopes[name]; ok {
+				return transportName + ":" + name, req
+			}
+		}
+
+		if req, ok := transportScopes[""]; ok {
+			return transportName, req
+		}
+	}
+
+	return "default", policy.Default
+}
+
+func policyRequirementType(req signature.PolicyRequirement) string {
+	var common struct {
+		Type string `json:"type"`
+	}
+	raw, err := json.Marshal(req)
+	if err != nil || json.Unmarshal(raw, &common) != nil || common.Type == "" {
+		return "unknown"
 	}
-	return signature.NewPolicyContext(policy)
+	return common.Type
 }
@@ -5,36 +5,50 @@ import (
 	"fmt"
 	skopeoPkg "github.com/bsquare-corp/terraform-provider-skopeo2/pkg/skopeo"
 	"io"
+	"slices"
 
 	"github.com/containers/common/pkg/retry"
 	"github.com/containers/image/v5/copy"
 	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/opencontainers/go-digest"
 
 	encconfig "github.com/containers/ocicrypt/config"
 	enchelpers "github.com/containers/ocicrypt/helpers"
 )
 
 type CopyOptions struct {
-	ReportWriter      io.Writer
-	SrcImage          *skopeoPkg.ImageOptions
-	DestImage         *skopeoPkg.ImageDestOptions
-	RetryOpts         *retry.RetryOptions
-	AdditionalTags    []string // For docker-archive: destinations, in addition to the name:tag specified as destination, also add these
-	PreserveDigests   bool     // Fail if we cannot preserve the source digests in the destination image
-	All               bool     // Copy all of the images if the source is a list
-	removeSignatures  bool     // Do not copy signatures from the source image
-	signByFingerprint string   // Sign the image using a GPG key with the specified fingerprint
-	format            string
-	quiet             bool     // Suppress output information when copying images
-	encryptLayer      []int    // The list of layers to encrypt
-	encryptionKeys    []string // Keys needed to encrypt the image
-	decryptionKeys    []string // Keys needed to decrypt the image
+	ReportWriter                     io.Writer
+	SrcImage                         *skopeoPkg.ImageOptions
+	DestImage                        *skopeoPkg.ImageDestOptions
+	RetryOpts                        *retry.RetryOptions
+	AdditionalTags                   []string // For docker-archive: destinations, in addition to the name:tag specified as destination, also add these
+	PreserveDigests                  bool     // Fail if we cannot preserve the source digests in the destination image
+	All                              bool     // Copy all of the images if the source is a list
+	Platforms                        []string // Copy the images for these os/architecture[/variant] platforms from the source list
+	RemoveSignatures                 bool     // Do not copy signatures from the source image
+	SignByFingerprint                string   // Sign the image using a GPG key with the specified fingerprint
+	SignPassphrase                   string   // Passphrase of the GPG key used with SignByFingerprint
+	SignGPGHome                      string   // GPG home directory holding the SignByFingerprint key, the GPG default if empty
+	SignBySigstorePrivateKeyFile     string   // Sign the image using a sigstore private key file
+	SignBySigstorePrivateKey         []byte   // PEM sigstore private key, used when SignBySigstorePrivateKeyFile is empty
+	SignSigstorePrivateKeyPassphrase []byte   // Passphrase of the sigstore private key
+	SignIdentity                     string   // Identity recorded in signatures, defaults to the destination reference
+	Format                           string   // Convert the manifest to this format, one of oci, v2s1 or v2s2
+	ForceCompressionFormat           bool     // Recompress layers already compressed in a format other than DestImage.CompressionFormat
+	quiet                            bool     // Suppress output information when copying images
+	EncryptLayer                     []int    // The list of layers to encrypt, all layers if empty
+	EncryptionKeys                   []string // Keys needed to encrypt the image
+	DecryptionKeys                   []string // Keys needed to decrypt the image, file paths or inline PEM
 }
 
 type CopyResult struct {
-	Digest string
+	Digest             string
+	SignedIdentity     string            // Identity recorded in the signatures added to the destination, empty if not signed
+	SigstoreAttachment string            // Image holding the sigstore signatures added to a registry destination, if any
+	Encrypted          bool              // The destination layers were encrypted
+	PlatformDigests    map[string]string // Destination digest of each of the selected platforms
 }
 
 func Copy(ctx context.Context, sourceImageName, destinationImageName string, opts *CopyOptions) (*CopyResult, error) {
@@ -43,14 +57,19 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 		return nil, err
 	}
 
-	insecurePolicy := false
-	policyPath := ""
-	policyContext, err := getPolicyContext(insecurePolicy, policyPath)
+	policyContext, policy, err := getPolicyContext(opts.SrcImage.Global)
 	if err != nil {
 		return nil, fmt.Errorf("Error loading trust policy: %v", err)
 	}
 	defer policyContext.Destroy()
 
+	if err := skopeoPkg.CheckShortName(opts.SrcImage.Global, sourceImageName); err != nil {
+		return nil, err
+	}
+	if err := skopeoPkg.CheckShortName(opts.DestImage.Global, destinationImageName); err != nil {
+		return nil, err
+	}
+
 	srcRef, err := alltransports.ParseImageName(sourceImageName)
 	if err != nil {
 		return nil, fmt.Errorf("Invalid source name %s: %v", sourceImageName, err)
@@ -70,8 +89,8 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 	}
 
 	var manifestType string
-	if opts.format != "" {
-		manifestType, err = skopeoPkg.ParseManifestFormat(opts.format)
+	if opts.Format != "" {
+		manifestType, err = skopeoPkg.ParseManifestFormat(opts.Format)
 		if err != nil {
 			return nil, err
 		}
@@ -94,7 +113,36 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 		imageListSelection = copy.CopyAllImages
 	}
 
-	if len(opts.encryptionKeys) > 0 && len(opts.decryptionKeys) > 0 {
+	var srcList manifest.List
+	var platformInstanceDigests map[string]digest.Digest
+	var instances []digest.Digest
+	if len(opts.Platforms) > 0 {
+		srcList, platformInstanceDigests, err = platformInstances(ctx, srcRef, sourceCtx, opts.Platforms, opts.RetryOpts)
+		if err != nil {
+			return nil, err
+		}
+		imageListSelection = copy.CopySpecificImages
+		for _, platform := range opts.Platforms {
+			if instance := platformInstanceDigests[platform]; !slices.Contains(instances, instance) {
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	if dirs := spaceDirs(srcRef, destRef, destinationCtx); len(dirs) > 0 {
+		var size int64
+		if err := retry.IfNecessary(ctx, func() error {
+			size, err = requiredSpace(ctx, srcRef, sourceCtx, imageListSelection, instances)
+			return err
+		}, opts.RetryOpts); err != nil {
+			return nil, err
+		}
+		if err := checkFreeSpace(dirs, size); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.EncryptionKeys) > 0 && len(opts.DecryptionKeys) > 0 {
 		return nil, fmt.Errorf("--encryption-key and --decryption-key cannot be specified together")
 	}
 
@@ -102,15 +150,15 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 	var encConfig *encconfig.EncryptConfig
 	var decConfig *encconfig.DecryptConfig
 
-	if len(opts.encryptLayer) > 0 && len(opts.encryptionKeys) == 0 {
+	if len(opts.EncryptLayer) > 0 && len(opts.EncryptionKeys) == 0 {
 		return nil, fmt.Errorf("--encrypt-layer can only be used with --encryption-key")
 	}
 
-	if len(opts.encryptionKeys) > 0 {
+	if len(opts.EncryptionKeys) > 0 {
 		// encryption
-		p := opts.encryptLayer
+		p := opts.EncryptLayer
 		encLayers = &p
-		encryptionKeys := opts.encryptionKeys
+		encryptionKeys := opts.EncryptionKeys
 		ecc, err := enchelpers.CreateCryptoConfig(encryptionKeys, []string{})
 		if err != nil {
 			return nil, fmt.Errorf("Invalid encryption keys: %v", err)
@@ -119,30 +167,70 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 		encConfig = cc.EncryptConfig
 	}
 
-	if len(opts.decryptionKeys) > 0 {
+	if len(opts.DecryptionKeys) > 0 {
 		// decryption
-		decryptionKeys := opts.decryptionKeys
-		dcc, err := enchelpers.CreateCryptoConfig([]string{}, decryptionKeys)
+		decConfig, err = newDecryptConfig(opts.DecryptionKeys)
 		if err != nil {
-			return nil, fmt.Errorf("Invalid decryption keys: %v", err)
+			return nil, err
+		}
+	}
+	var signIdentity reference.Named
+	if opts.SignIdentity != "" {
+		signIdentity, err = reference.ParseNamed(opts.SignIdentity)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid signature identity %s: %v", opts.SignIdentity, err)
+		}
+	}
+
+	sigstoreKeyFile := opts.SignBySigstorePrivateKeyFile
+	if sigstoreKeyFile == "" && len(opts.SignBySigstorePrivateKey) > 0 {
+		var removeKeyFile func()
+		sigstoreKeyFile, removeKeyFile, err = writeKeyFile("sigstore-key", opts.SignBySigstorePrivateKey)
+		if err != nil {
+			return nil, err
 		}
-		cc := encconfig.CombineCryptoConfigs([]encconfig.CryptoConfig{dcc})
-		decConfig = cc.DecryptConfig
+		defer removeKeyFile()
 	}
+
+	sigstoreAttachments := sigstoreKeyFile != "" && destRef.Transport().Name() == "docker"
+	if sigstoreAttachments {
+		var removeRegistriesDir func()
+		destinationCtx.RegistriesDirPath, removeRegistriesDir, err = withSigstoreAttachments(destinationCtx, destRef)
+		if err != nil {
+			return nil, err
+		}
+		defer removeRegistriesDir()
+	}
+
+	if opts.SignByFingerprint != "" && opts.SignGPGHome != "" {
+		restoreGPGHome, err := useGPGHome(opts.SignGPGHome)
+		if err != nil {
+			return nil, err
+		}
+		defer restoreGPGHome()
+	}
+
 	var manifestBytes []byte
 	err = retry.RetryIfNecessary(ctx, func() error {
 		manifestBytes, err = copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
-			RemoveSignatures:      opts.removeSignatures,
-			SignBy:                opts.signByFingerprint,
-			ReportWriter:          opts.ReportWriter,
-			SourceCtx:             sourceCtx,
-			DestinationCtx:        destinationCtx,
-			ForceManifestMIMEType: manifestType,
-			ImageListSelection:    imageListSelection,
-			PreserveDigests:       opts.PreserveDigests,
-			OciDecryptConfig:      decConfig,
-			OciEncryptLayers:      encLayers,
-			OciEncryptConfig:      encConfig,
+			RemoveSignatures: opts.RemoveSignatures,
+			SignBy:           opts.SignByFingerprint,
+			SignPassphrase:   opts.SignPassphrase,
+			SignIdentity:     signIdentity,
+
+			SignBySigstorePrivateKeyFile:     sigstoreKeyFile,
+			SignSigstorePrivateKeyPassphrase: opts.SignSigstorePrivateKeyPassphrase,
+			ReportWriter:                     opts.ReportWriter,
+			SourceCtx:                        sourceCtx,
+			DestinationCtx:                   destinationCtx,
+			ForceManifestMIMEType:            manifestType,
+			ImageListSelection:               imageListSelection,
+			Instances:                        instances,
+			PreserveDigests:                  opts.PreserveDigests,
+			ForceCompressionFormat:           opts.ForceCompressionFormat,
+			OciDecryptConfig:                 decConfig,
+			OciEncryptLayers:                 encLayers,
+			OciEncryptConfig:                 encConfig,
 		})
 		if err != nil {
 			return err
@@ -150,7 +238,7 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 		return nil
 	}, opts.RetryOpts)
 	if err != nil {
-		return nil, err
+		return nil, policyRejection(policy, srcRef, err)
 	}
 
 	manifestDigest, err := manifest.Digest(manifestBytes)
@@ -158,7 +246,38 @@ func Copy(ctx context.Context, sourceImageName, destinationImageName string, opt
 		return nil, err
 	}
 
+	var platforms map[string]string
+	if srcList != nil {
+		platforms, err = platformDigests(srcList, platformInstanceDigests, manifestBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var signedIdentity string
+	if opts.SignByFingerprint != "" || sigstoreKeyFile != "" {
+		if signIdentity != nil {
+			signedIdentity = signIdentity.String()
+		} else if destRef.DockerReference() != nil {
+			signedIdentity = destRef.DockerReference().String()
+		}
+	}
+
+	var sigstoreAttachment string
+	if sigstoreAttachments {
+		attachmentRef, err := reference.WithTag(reference.TrimNamed(destRef.DockerReference()),
+			SigstoreAttachmentTag(manifestDigest))
+		if err != nil {
+			return nil, err
+		}
+		sigstoreAttachment = "docker://" + attachmentRef.String()
+	}
+
 	return &CopyResult{
-		Digest: string(manifestDigest.String()),
+		Digest:             string(manifestDigest.String()),
+		SignedIdentity:     signedIdentity,
+		SigstoreAttachment: sigstoreAttachment,
+		Encrypted:          encConfig != nil,
+		PlatformDigests:    platforms,
 	}, nil
 }
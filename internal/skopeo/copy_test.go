@@ -2,6 +2,7 @@ package skopeo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -57,6 +58,50 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestCopyPolicyRejected(t *testing.T) {
+
+	t.Parallel()
+
+	reportWriter := providerlog.NewProviderLogWriter(
+		log.Default().Writer(),
+	)
+	defer reportWriter.Close()
+
+	global := &skopeoPkg.GlobalOptions{
+		Debug:      true,
+		PolicyJSON: `{"default": [{"type": "insecureAcceptAnything"}], "transports": {"docker": {"docker.io/library": [{"type": "reject"}]}}}`,
+	}
+	writeDir := t.TempDir()
+	_, err := Copy(context.TODO(), "docker://alpine:latest", fmt.Sprintf("dir:%s", writeDir), &CopyOptions{
+		ReportWriter: reportWriter,
+		SrcImage: &skopeoPkg.ImageOptions{
+			DockerImageOptions: skopeoPkg.DockerImageOptions{
+				Global: global,
+				Shared: &skopeoPkg.SharedImageOptions{},
+			},
+		},
+		DestImage: &skopeoPkg.ImageDestOptions{
+			ImageOptions: &skopeoPkg.ImageOptions{
+				DockerImageOptions: skopeoPkg.DockerImageOptions{
+					Global: global,
+					Shared: &skopeoPkg.SharedImageOptions{},
+				},
+			},
+		},
+		RetryOpts: &retry.RetryOptions{},
+	})
+	var rejection *PolicyRejectionError
+	if !errors.As(err, &rejection) {
+		t.Fatalf("Expected a policy rejection, got %v", err)
+	}
+	if rejection.Scope != "docker:docker.io/library" {
+		t.Fatalf("Unexpected rejecting scope %s", rejection.Scope)
+	}
+	if len(rejection.Requirements) != 1 || rejection.Requirements[0] != "reject" {
+		t.Fatalf("Unexpected rejecting requirements %v", rejection.Requirements)
+	}
+}
+
 func readDir(t *testing.T, dir string) (entries []string) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
@@ -0,0 +1,63 @@
+package skopeo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/types"
+	"github.com/containers/ocicrypt"
+	encconfig "github.com/containers/ocicrypt/config"
+	enchelpers "github.com/containers/ocicrypt/helpers"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// isInlineKey reports whether a decryption key is given as PEM content rather than a file path
+func isInlineKey(key string) bool {
+	return strings.HasPrefix(strings.TrimSpace(key), "-----BEGIN")
+}
+
+// newDecryptConfig builds the decryption configuration for keys, each of which is a private key file path
+// in ocicrypt format (optionally followed by a passphrase) or an inline PEM private key.
+func newDecryptConfig(keys []string) (*encconfig.DecryptConfig, error) {
+	keyFiles := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !isInlineKey(key) {
+			keyFiles = append(keyFiles, key)
+			continue
+		}
+		// ocicrypt only reads keys from files, it has finished with them once the config is created
+		keyFile, removeKeyFile, err := writeKeyFile("decryption-key", []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		defer removeKeyFile()
+		keyFiles = append(keyFiles, keyFile)
+	}
+
+	dcc, err := enchelpers.CreateCryptoConfig([]string{}, keyFiles)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid decryption keys: %v", err)
+	}
+	cc := encconfig.CombineCryptoConfigs([]encconfig.CryptoConfig{dcc})
+	return cc.DecryptConfig, nil
+}
+
+// checkDecryption confirms that decConfig holds a key able to decrypt each of the encrypted layers,
+// without reading the layers themselves.
+func checkDecryption(decConfig *encconfig.DecryptConfig, layers []types.BlobInfo) error {
+	for _, layer := range layers {
+		if !strings.HasSuffix(layer.MediaType, "+encrypted") {
+			continue
+		}
+		desc := imgspecv1.Descriptor{
+			MediaType:   layer.MediaType,
+			Digest:      layer.Digest,
+			Size:        layer.Size,
+			Annotations: layer.Annotations,
+		}
+		if _, _, err := ocicrypt.DecryptLayer(decConfig, nil, desc, true); err != nil {
+			return fmt.Errorf("cannot decrypt layer %s: %w", layer.Digest, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package skopeo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/types"
+	"github.com/containers/ocicrypt"
+	encconfig "github.com/containers/ocicrypt/config"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newTestRSAKey(t *testing.T) (privatePEM, publicPEM []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func TestCheckDecryption(t *testing.T) {
+	privateKey, publicKey := newTestRSAKey(t)
+	otherKey, _ := newTestRSAKey(t)
+
+	// Encrypt a layer for the public key to obtain the annotations recorded in a manifest
+	ecc, err := encconfig.EncryptWithJwe([][]byte{publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerData := []byte("layer data")
+	reader, finalizer, err := ocicrypt.EncryptLayer(ecc.EncryptConfig, bytes.NewReader(layerData),
+		imgspecv1.Descriptor{Digest: digest.FromBytes(layerData), Size: int64(len(layerData))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := finalizer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers := []types.BlobInfo{
+		{Digest: digest.FromString("plain"), MediaType: imgspecv1.MediaTypeImageLayerGzip},
+		{Digest: digest.FromString("encrypted"), MediaType: imgspecv1.MediaTypeImageLayerGzip + "+encrypted",
+			Annotations: annotations},
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "private.pem")
+	if err := os.WriteFile(keyFile, privateKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		keys  []string
+		valid bool
+	}{
+		"inline key": {keys: []string{string(privateKey)}, valid: true},
+		"key file":   {keys: []string{keyFile}, valid: true},
+		"other key":  {keys: []string{string(otherKey)}, valid: false},
+	}
+
+	for name, c := range cases {
+		decConfig, err := newDecryptConfig(c.keys)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		err = checkDecryption(decConfig, layers)
+		if c.valid && err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected the layer not to be decryptable", name)
+		}
+	}
+}
@@ -17,8 +17,10 @@ import (
 )
 
 type InspectOptions struct {
-	Image     *skopeoPkg.ImageOptions
-	RetryOpts *retry.RetryOptions
+	Image          *skopeoPkg.ImageOptions
+	RetryOpts      *retry.RetryOptions
+	VerifyPolicy   bool     // Fail unless the image is allowed by the signature trust policy in Image.Global
+	DecryptionKeys []string // Fail unless these keys can decrypt every encrypted layer, file paths or inline PEM
 }
 
 type InspectOutput struct {
@@ -73,11 +75,28 @@ func Inspect(ctx context.Context, imageName string, opts *InspectOptions) (out *
 		return nil, errors.Wrapf(err, "error computing manifest digest")
 	}
 
-	img, err := image.FromUnparsedImage(ctx, sysCtx, image.UnparsedInstance(src, nil))
+	unparsedImage := image.UnparsedInstance(src, nil)
+	if opts.VerifyPolicy {
+		if err := verifyPolicy(ctx, opts.Image.Global, unparsedImage); err != nil {
+			return nil, err
+		}
+	}
+
+	img, err := image.FromUnparsedImage(ctx, sysCtx, unparsedImage)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing manifest for image: %w", err)
 	}
 
+	if len(opts.DecryptionKeys) > 0 {
+		decConfig, err := newDecryptConfig(opts.DecryptionKeys)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkDecryption(decConfig, img.LayerInfos()); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := retry.IfNecessary(ctx, func() error {
 		imgInspect, err = img.Inspect(ctx)
 		return err
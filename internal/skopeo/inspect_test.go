@@ -2,6 +2,7 @@ package skopeo
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	skopeoPkg "github.com/bsquare-corp/terraform-provider-skopeo2/pkg/skopeo"
@@ -29,3 +30,29 @@ func TestInspect(t *testing.T) {
 		t.Fatal("Digest not expected")
 	}
 }
+
+func TestInspectShortNameRejected(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"docker://alpine:latest", "docker://library/alpine:latest"} {
+		_, err := Inspect(context.TODO(), name, &InspectOptions{
+			Image: &skopeoPkg.ImageOptions{
+				DockerImageOptions: skopeoPkg.DockerImageOptions{
+					Global: &skopeoPkg.GlobalOptions{
+						RejectShortNames: true,
+					},
+					Shared: &skopeoPkg.SharedImageOptions{},
+				},
+			},
+			RetryOpts: &retry.RetryOptions{},
+		})
+		if err == nil || !strings.Contains(err.Error(), "short name "+name+" is rejected") {
+			t.Errorf("%s: expected short name rejection, got %v", name, err)
+		}
+	}
+
+	if err := skopeoPkg.CheckShortName(&skopeoPkg.GlobalOptions{RejectShortNames: true},
+		"docker://docker.io/library/alpine:latest"); err != nil {
+		t.Errorf("fully qualified name rejected: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package skopeo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/transports"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ParsePlatform parses a platform given as os/architecture[/variant], e.g. linux/arm64/v8
+func ParsePlatform(platform string) (*imgspecv1.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid platform %q, expected os/architecture[/variant]", platform)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid platform %q, expected os/architecture[/variant]", platform)
+		}
+	}
+	p := &imgspecv1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// platformInstances resolves each of platforms to an instance of the manifest list at srcRef, failing if the
+// image is not a list or any of the platforms is missing from it.
+func platformInstances(ctx context.Context, srcRef types.ImageReference, sys *types.SystemContext, platforms []string,
+	retryOpts *retry.RetryOptions) (manifest.List, map[string]digest.Digest, error) {
+	var rawManifest []byte
+	var mimeType string
+	if err := retry.IfNecessary(ctx, func() error {
+		src, err := srcRef.NewImageSource(ctx, sys)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		rawManifest, mimeType, err = src.GetManifest(ctx, nil)
+		return err
+	}, retryOpts); err != nil {
+		return nil, nil, fmt.Errorf("error retrieving manifest for image: %w", err)
+	}
+
+	if !manifest.MIMETypeIsMultiImage(mimeType) {
+		return nil, nil, fmt.Errorf("platforms can only be selected from a multiple image manifest, %s is a single image",
+			transports.ImageName(srcRef))
+	}
+	list, err := manifest.ListFromBlob(rawManifest, mimeType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing manifest list: %w", err)
+	}
+
+	instances := make(map[string]digest.Digest, len(platforms))
+	for _, platform := range platforms {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, nil, err
+		}
+		instance, err := list.ChooseInstance(&types.SystemContext{
+			OSChoice:           p.OS,
+			ArchitectureChoice: p.Architecture,
+			VariantChoice:      p.Variant,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("platform %s is missing from %s: %w", platform, transports.ImageName(srcRef), err)
+		}
+		instances[platform] = instance
+	}
+	return list, instances, nil
+}
+
+// platformDigests maps the platform instances chosen from srcList to their digests in the copied destList,
+// which keeps the order of the source list even when the copy rewrites the instances.
+func platformDigests(srcList manifest.List, instances map[string]digest.Digest, destList []byte) (map[string]string, error) {
+	dest, err := manifest.ListFromBlob(destList, manifest.GuessMIMEType(destList))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing destination manifest list: %w", err)
+	}
+	srcInstances := srcList.Instances()
+	destInstances := dest.Instances()
+	if len(srcInstances) != len(destInstances) {
+		return nil, fmt.Errorf("destination manifest list has %d instances, expected %d", len(destInstances),
+			len(srcInstances))
+	}
+
+	digests := make(map[string]string, len(instances))
+	for platform, instance := range instances {
+		i := slices.Index(srcInstances, instance)
+		if i < 0 {
+			return nil, fmt.Errorf("instance %s of platform %s is missing from the source manifest list", instance, platform)
+		}
+		digests[platform] = destInstances[i].String()
+	}
+	return digests, nil
+}
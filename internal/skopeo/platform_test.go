@@ -0,0 +1,101 @@
+package skopeo
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containers/common/pkg/retry"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// writeOCIIndexLayout writes an oci: layout at dir whose "latest" image is index, without the instances it lists
+func writeOCIIndexLayout(t *testing.T, dir string, index []byte) {
+	indexDigest := digest.FromBytes(index)
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blobs", "sha256", indexDigest.Encoded()), index, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	topLevel, err := json.Marshal(imgspecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{{
+			MediaType:   imgspecv1.MediaTypeImageIndex,
+			Digest:      indexDigest,
+			Size:        int64(len(index)),
+			Annotations: map[string]string{imgspecv1.AnnotationRefName: "latest"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), topLevel, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlatformInstances(t *testing.T) {
+	amd64 := digest.FromString("amd64")
+	arm64 := digest.FromString("arm64")
+	index := manifest.OCI1IndexFromComponents([]imgspecv1.Descriptor{
+		{MediaType: imgspecv1.MediaTypeImageManifest, Digest: amd64, Size: 1,
+			Platform: &imgspecv1.Platform{OS: "linux", Architecture: "amd64"}},
+		{MediaType: imgspecv1.MediaTypeImageManifest, Digest: arm64, Size: 1,
+			Platform: &imgspecv1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+	}, nil)
+	indexBytes, err := index.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeOCIIndexLayout(t, dir, indexBytes)
+	srcRef, err := alltransports.ParseImageName("oci:" + dir + ":latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, instances, err := platformInstances(context.Background(), srcRef, nil,
+		[]string{"linux/amd64", "linux/arm64"}, &retry.RetryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instances["linux/amd64"] != amd64 || instances["linux/arm64"] != arm64 {
+		t.Errorf("unexpected instances %v", instances)
+	}
+
+	_, _, err = platformInstances(context.Background(), srcRef, nil, []string{"linux/s390x"}, &retry.RetryOptions{})
+	if err == nil || !strings.Contains(err.Error(), "platform linux/s390x is missing") {
+		t.Errorf("expected missing platform error, got %v", err)
+	}
+
+	// The copy rewrote the instances, their digests are found by position
+	copiedAmd64 := digest.FromString("copied amd64")
+	copiedArm64 := digest.FromString("copied arm64")
+	copied := manifest.OCI1IndexFromComponents([]imgspecv1.Descriptor{
+		{MediaType: imgspecv1.MediaTypeImageManifest, Digest: copiedAmd64, Size: 1},
+		{MediaType: imgspecv1.MediaTypeImageManifest, Digest: copiedArm64, Size: 1},
+	}, nil)
+	copiedBytes, err := copied.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests, err := platformDigests(list, instances, copiedBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digests["linux/amd64"] != copiedAmd64.String() || digests["linux/arm64"] != copiedArm64.String() {
+		t.Errorf("unexpected platform digests %v", digests)
+	}
+}
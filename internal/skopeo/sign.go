@@ -0,0 +1,162 @@
+package skopeo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/containers/image/v5/types"
+	"github.com/containers/storage/pkg/homedir"
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// GPG signing only honours GNUPGHOME, so copies using a specific GPG home directory are serialised
+	gpgHomeInUse sync.Mutex
+)
+
+// useGPGHome points GPG at dir for the duration of a copy. The returned function restores the previous
+// environment and must be called once signing has completed.
+func useGPGHome(dir string) (func(), error) {
+	gpgHomeInUse.Lock()
+	previous, wasSet := os.LookupEnv("GNUPGHOME")
+	if err := os.Setenv("GNUPGHOME", dir); err != nil {
+		gpgHomeInUse.Unlock()
+		return nil, err
+	}
+	return func() {
+		if wasSet {
+			_ = os.Setenv("GNUPGHOME", previous)
+		} else {
+			_ = os.Unsetenv("GNUPGHOME")
+		}
+		gpgHomeInUse.Unlock()
+	}, nil
+}
+
+// registriesDConfig mirrors the registries.d configuration read by the docker transport
+type registriesDConfig struct {
+	DefaultDocker *registriesDNamespace           `yaml:"default-docker,omitempty"`
+	Docker        map[string]registriesDNamespace `yaml:"docker,omitempty"`
+}
+
+type registriesDNamespace struct {
+	Lookaside              string `yaml:"lookaside,omitempty"`
+	LookasideStaging       string `yaml:"lookaside-staging,omitempty"`
+	SigStore               string `yaml:"sigstore,omitempty"`
+	SigStoreStaging        string `yaml:"sigstore-staging,omitempty"`
+	UseSigstoreAttachments *bool  `yaml:"use-sigstore-attachments,omitempty"`
+}
+
+// registriesDirPath returns the registries.d directory the docker transport uses for sys
+func registriesDirPath(sys *types.SystemContext) string {
+	if sys.RegistriesDirPath != "" {
+		return sys.RegistriesDirPath
+	}
+	userRegistriesDirPath := filepath.Join(homedir.Get(), ".config/containers/registries.d")
+	if _, err := os.Stat(userRegistriesDirPath); err == nil {
+		return userRegistriesDirPath
+	}
+	return "/etc/containers/registries.d"
+}
+
+// loadRegistriesD merges the registries.d configuration files in dirPath
+func loadRegistriesD(dirPath string) (*registriesDConfig, error) {
+	merged := &registriesDConfig{Docker: map[string]registriesDNamespace{}}
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return merged, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+		configPath := filepath.Join(dirPath, entry.Name())
+		configBytes, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		var config registriesDConfig
+		if err := yaml.Unmarshal(configBytes, &config); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", configPath, err)
+		}
+		if config.DefaultDocker != nil {
+			merged.DefaultDocker = config.DefaultDocker
+		}
+		for name, ns := range config.Docker {
+			merged.Docker[name] = ns
+		}
+	}
+	return merged, nil
+}
+
+// withSigstoreAttachments returns a registries.d directory equivalent to the one used by sys which also enables
+// sigstore attachments for ref, unless the configuration already decides whether to use them. The returned function
+// removes any directory created and must be called once the copy has completed.
+func withSigstoreAttachments(sys *types.SystemContext, ref types.ImageReference) (string, func(), error) {
+	dirPath := registriesDirPath(sys)
+	config, err := loadRegistriesD(dirPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	namespaces := append([]string{ref.PolicyConfigurationIdentity()}, ref.PolicyConfigurationNamespaces()...)
+	for _, name := range namespaces {
+		if ns, ok := config.Docker[name]; ok && ns.UseSigstoreAttachments != nil {
+			return dirPath, func() {}, nil
+		}
+	}
+	if config.DefaultDocker != nil && config.DefaultDocker.UseSigstoreAttachments != nil {
+		return dirPath, func() {}, nil
+	}
+
+	enabled := true
+	ns := config.Docker[namespaces[0]]
+	ns.UseSigstoreAttachments = &enabled
+	config.Docker[namespaces[0]] = ns
+
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return "", nil, err
+	}
+	tmpDir, err := os.MkdirTemp("", "registries.d")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+	if err := os.WriteFile(filepath.Join(tmpDir, "skopeo2.yaml"), configBytes, 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmpDir, cleanup, nil
+}
+
+// writeKeyFile writes a PEM key to a file readable only by the current user, for the signing and encryption
+// libraries which only accept key files. The returned function removes the file.
+func writeKeyFile(pattern string, key []byte) (string, func(), error) {
+	keyFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.Remove(keyFile.Name()) }
+	_, err = keyFile.Write(key)
+	if closeErr := keyFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return keyFile.Name(), cleanup, nil
+}
+
+// SigstoreAttachmentTag returns the tag under which a registry stores the sigstore signatures of manifestDigest
+func SigstoreAttachmentTag(manifestDigest digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", manifestDigest.Algorithm(), manifestDigest.Encoded())
+}
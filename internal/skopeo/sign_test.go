@@ -0,0 +1,68 @@
+package skopeo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+)
+
+func TestWithSigstoreAttachments(t *testing.T) {
+	t.Parallel()
+
+	ref, err := alltransports.ParseImageName("docker://registry.example.com/team/app:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfig := func(t *testing.T, config string) string {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "team.yaml"), []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		dir := writeConfig(t, "docker:\n  registry.example.com/team:\n    lookaside-staging: file:///tmp/sigs\n")
+		overlay, cleanup, err := withSigstoreAttachments(&types.SystemContext{RegistriesDirPath: dir}, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if overlay == dir {
+			t.Fatal("Expected a new registries.d directory")
+		}
+
+		config, err := loadRegistriesD(overlay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ns, ok := config.Docker["registry.example.com/team/app:1.0"]
+		if !ok || ns.UseSigstoreAttachments == nil || !*ns.UseSigstoreAttachments {
+			t.Fatalf("Sigstore attachments not enabled for the image: %+v", config.Docker)
+		}
+		if config.Docker["registry.example.com/team"].LookasideStaging != "file:///tmp/sigs" {
+			t.Fatalf("Existing configuration not preserved: %+v", config.Docker)
+		}
+
+		cleanup()
+		if _, err := os.Stat(overlay); !os.IsNotExist(err) {
+			t.Fatalf("Expected %s to be removed", overlay)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		dir := writeConfig(t, "default-docker:\n  use-sigstore-attachments: false\n")
+		overlay, cleanup, err := withSigstoreAttachments(&types.SystemContext{RegistriesDirPath: dir}, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if overlay != dir {
+			t.Fatalf("Expected the configured registries.d directory to be used, got %s", overlay)
+		}
+	})
+}
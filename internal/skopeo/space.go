@@ -0,0 +1,148 @@
+package skopeo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+)
+
+// InsufficientSpaceError is returned when a copy would not fit in the free space of a directory it writes to.
+type InsufficientSpaceError struct {
+	Dir       string // The directory which lacks space
+	Temporary bool   // Dir is where the copy stages temporary files, rather than the destination
+	Required  int64  // Total size of the source image blobs
+	Available int64  // Space available to the copy in Dir
+}
+
+// spaceDir is a local directory a copy writes the image blobs to
+type spaceDir struct {
+	path      string
+	temporary bool
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("%s has %d bytes available, the image needs %d bytes", e.Dir, e.Available, e.Required)
+}
+
+// spaceDirs returns the local directories which a copy from srcRef to destRef fills with the image blobs.
+func spaceDirs(srcRef, destRef types.ImageReference, destCtx *types.SystemContext) []spaceDir {
+	var dirs []spaceDir
+	// These transports cannot stream blobs, the copy stages them in the big files temporary directory
+	for _, ref := range []types.ImageReference{srcRef, destRef} {
+		switch ref.Transport().Name() {
+		case "docker-archive", "oci-archive", "docker-daemon":
+			tmpDir := destCtx.BigFilesTemporaryDir
+			if tmpDir == "" {
+				tmpDir = os.TempDir()
+			}
+			if len(dirs) == 0 {
+				dirs = append(dirs, spaceDir{path: tmpDir, temporary: true})
+			}
+		}
+	}
+
+	path := destRef.StringWithinTransport()
+	switch destRef.Transport().Name() {
+	case "dir":
+		dirs = append(dirs, spaceDir{path: existingParent(path)})
+	case "oci":
+		path, _, _ = strings.Cut(path, ":")
+		dirs = append(dirs, spaceDir{path: existingParent(path)})
+	case "docker-archive", "oci-archive":
+		path, _, _ = strings.Cut(path, ":")
+		dirs = append(dirs, spaceDir{path: existingParent(filepath.Dir(path))})
+	}
+	return dirs
+}
+
+// existingParent returns path or its closest ancestor which exists, as a copy creates the directories it needs
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// requiredSpace sums the sizes of the config and layers of the images which the copy selects from srcRef.
+// Blobs of unknown size are not counted.
+func requiredSpace(ctx context.Context, srcRef types.ImageReference, sys *types.SystemContext,
+	selection copy.ImageListSelection, instances []digest.Digest) (int64, error) {
+	src, err := srcRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	rawManifest, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving manifest for image: %w", err)
+	}
+
+	var selected []*digest.Digest
+	if !manifest.MIMETypeIsMultiImage(mimeType) {
+		selected = []*digest.Digest{nil}
+	} else {
+		list, err := manifest.ListFromBlob(rawManifest, mimeType)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing manifest list: %w", err)
+		}
+		switch selection {
+		case copy.CopyAllImages:
+			instances = list.Instances()
+		case copy.CopySystemImage:
+			instance, err := list.ChooseInstance(sys)
+			if err != nil {
+				return 0, err
+			}
+			instances = []digest.Digest{instance}
+		}
+		for i := range instances {
+			selected = append(selected, &instances[i])
+		}
+	}
+
+	var size int64
+	for _, instance := range selected {
+		img, err := image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, instance))
+		if err != nil {
+			return 0, fmt.Errorf("error parsing manifest for image: %w", err)
+		}
+		blobs := append([]types.BlobInfo{img.ConfigInfo()}, img.LayerInfos()...)
+		for _, blob := range blobs {
+			if blob.Size > 0 {
+				size += blob.Size
+			}
+		}
+	}
+	return size, nil
+}
+
+// checkFreeSpace fails with an *InsufficientSpaceError if any of dirs has less than size bytes available
+func checkFreeSpace(dirs []spaceDir, size int64) error {
+	for _, dir := range dirs {
+		available, err := freeSpace(dir.path)
+		if err != nil {
+			return fmt.Errorf("error determining the free space of %s: %w", dir.path, err)
+		}
+		if available < size {
+			return &InsufficientSpaceError{Dir: dir.path, Temporary: dir.temporary, Required: size, Available: available}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package skopeo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestRequiredSpace(t *testing.T) {
+	instance := manifest.OCI1FromComponents(imgspecv1.Descriptor{
+		MediaType: imgspecv1.MediaTypeImageConfig, Digest: digest.FromString("config"), Size: 100,
+	}, []imgspecv1.Descriptor{
+		{MediaType: imgspecv1.MediaTypeImageLayerGzip, Digest: digest.FromString("layer 1"), Size: 1000},
+		{MediaType: imgspecv1.MediaTypeImageLayerGzip, Digest: digest.FromString("layer 2"), Size: 10000},
+	})
+	instanceBytes, err := instance.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	instanceDigest := digest.FromBytes(instanceBytes)
+	index := manifest.OCI1IndexFromComponents([]imgspecv1.Descriptor{
+		{MediaType: imgspecv1.MediaTypeImageManifest, Digest: instanceDigest, Size: int64(len(instanceBytes)),
+			Platform: &imgspecv1.Platform{OS: "linux", Architecture: "amd64"}},
+	}, nil)
+	indexBytes, err := index.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeOCIIndexLayout(t, dir, indexBytes)
+	if err := os.WriteFile(filepath.Join(dir, "blobs", "sha256", instanceDigest.Encoded()), instanceBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+	srcRef, err := alltransports.ParseImageName("oci:" + dir + ":latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sys := &types.SystemContext{OSChoice: "linux", ArchitectureChoice: "amd64"}
+	size, err := requiredSpace(context.Background(), srcRef, sys, copy.CopySystemImage, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 11100 {
+		t.Errorf("unexpected required space %d", size)
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	srcRef, err := alltransports.ParseImageName("docker://registry.example.com/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	destRef, err := alltransports.ParseImageName("docker-archive:" + filepath.Join(dir, "new", "image.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The archive is staged in the temporary directory, then written next to the missing new directory
+	dirs := spaceDirs(srcRef, destRef, &types.SystemContext{BigFilesTemporaryDir: dir})
+	if len(dirs) != 2 || dirs[0] != (spaceDir{path: dir, temporary: true}) || dirs[1] != (spaceDir{path: dir}) {
+		t.Fatalf("unexpected directories %v", dirs)
+	}
+
+	if err := checkFreeSpace(dirs, 1); err != nil {
+		t.Error(err)
+	}
+	var space *InsufficientSpaceError
+	if err := checkFreeSpace(dirs, math.MaxInt64); !errors.As(err, &space) || space.Dir != dir || !space.Temporary {
+		t.Errorf("expected insufficient temporary space, got %v", err)
+	}
+
+	// Copies between registries write no local files
+	destRef, err = alltransports.ParseImageName("docker://registry.example.com/copy:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirs := spaceDirs(srcRef, destRef, &types.SystemContext{}); len(dirs) != 0 {
+		t.Errorf("unexpected directories %v", dirs)
+	}
+}
@@ -18,6 +18,10 @@ func Delete(ctx context.Context, imageName string, opts *DeleteOptions) error {
 		return err
 	}
 
+	if err := CheckShortName(opts.Image.Global, imageName); err != nil {
+		return err
+	}
+
 	ref, err := alltransports.ParseImageName(imageName)
 	if err != nil {
 		return fmt.Errorf("Invalid source name %s: %v", imageName, err)
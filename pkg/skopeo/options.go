@@ -10,28 +10,34 @@ var defaultUserAgent = "terraform-provider-skopeo2/1.1.0"
 
 type GlobalOptions struct {
 	Debug              bool          // Enable debug output
-	policyPath         string        // Path to a signature verification policy file
-	insecurePolicy     bool          // Use an "allow everything" signature verification policy
-	registriesDirPath  string        // Path to a "registries.d" registry configuration directory
-	overrideArch       string        // Architecture to use for choosing images, instead of the runtime one
-	overrideOS         string        // OS to use for choosing images, instead of the runtime one
-	overrideVariant    string        // Architecture variant to use for choosing images, instead of the runtime one
+	PolicyPath         string        // Path to a signature verification policy file
+	PolicyJSON         string        // Signature verification policy document, used in preference to PolicyPath
+	InsecurePolicy     bool          // Use an "allow everything" signature verification policy
+	RegistriesDirPath  string        // Path to a "registries.d" registry configuration directory
+	OverrideArch       string        // Architecture to use for choosing images, instead of the runtime one
+	OverrideOS         string        // OS to use for choosing images, instead of the runtime one
+	OverrideVariant    string        // Architecture variant to use for choosing images, instead of the runtime one
 	commandTimeout     time.Duration // Timeout for the command execution
-	registriesConfPath string        // Path to the "registries.conf" file
-	tmpDir             string        // Path to use for big temporary files
+	RegistriesConfPath string        // Path to the "registries.conf" file
+	RejectShortNames   bool          // Fail rather than resolve docker: image names which do not include a registry
+	TmpDir             string        // Path to use for big temporary files, instead of the system default
 }
 
 // newSystemContext returns a *types.SystemContext corresponding to opts.
 // It is guaranteed to return a fresh instance, so it is safe to make additional updates to it.
 func (opts *GlobalOptions) newSystemContext() *types.SystemContext {
 	ctx := &types.SystemContext{
-		RegistriesDirPath:        opts.registriesDirPath,
-		ArchitectureChoice:       opts.overrideArch,
-		OSChoice:                 opts.overrideOS,
-		VariantChoice:            opts.overrideVariant,
-		SystemRegistriesConfPath: opts.registriesConfPath,
-		BigFilesTemporaryDir:     opts.tmpDir,
+		RegistriesDirPath:        opts.RegistriesDirPath,
+		ArchitectureChoice:       opts.OverrideArch,
+		OSChoice:                 opts.OverrideOS,
+		VariantChoice:            opts.OverrideVariant,
+		SystemRegistriesConfPath: opts.RegistriesConfPath,
+		BigFilesTemporaryDir:     opts.TmpDir,
 		DockerRegistryUserAgent:  defaultUserAgent,
 	}
+	if opts.RejectShortNames {
+		mode := types.ShortNameModeEnforcing
+		ctx.ShortNameMode = &mode
+	}
 	return ctx
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/containers/image/v5/docker/reference"
 	"github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/pkg/compression"
 	"github.com/containers/image/v5/transports/alltransports"
@@ -35,9 +36,9 @@ type DockerImageOptions struct {
 	credsOption    string              // username[:password] for accessing a registry
 	userName       string              // username for accessing a registry
 	password       string              // password for accessing a registry
-	registryToken  string              // token to be used directly as a Bearer token when accessing the registry
+	RegistryToken  string              // token to be used directly as a Bearer token when accessing the registry
 	DockerCertPath string              // A directory using Docker-like *.{crt,cert,key} files for connecting to a registry or a daemon
-	noCreds        bool                // Access the registry anonymously
+	NoCreds        bool                // Access the registry anonymously
 }
 
 // ImageOptions collects CLI flags which are the same across subcommands, but may be different for each image
@@ -67,10 +68,10 @@ func (opts *ImageOptions) NewSystemContext() (*types.SystemContext, error) {
 	if opts.DockerImageOptions.AuthFilePath != "" {
 		ctx.AuthFilePath = opts.DockerImageOptions.AuthFilePath
 	}
-	if opts.credsOption != "" && opts.noCreds {
+	if opts.credsOption != "" && opts.NoCreds {
 		return nil, errors.New("creds and no-creds cannot be specified at the same time")
 	}
-	if opts.userName != "" && opts.noCreds {
+	if opts.userName != "" && opts.NoCreds {
 		return nil, errors.New("username and no-creds cannot be specified at the same time")
 	}
 	if opts.credsOption != "" && opts.userName != "" {
@@ -95,10 +96,10 @@ func (opts *ImageOptions) NewSystemContext() (*types.SystemContext, error) {
 			Password: opts.password,
 		}
 	}
-	if opts.registryToken != "" {
-		ctx.DockerBearerRegistryToken = opts.registryToken
+	if opts.RegistryToken != "" {
+		ctx.DockerBearerRegistryToken = opts.RegistryToken
 	}
-	if opts.noCreds {
+	if opts.NoCreds {
 		ctx.DockerAuthConfig = &types.DockerAuthConfig{}
 	}
 
@@ -111,14 +112,14 @@ type ImageDestOptions struct {
 	dirForceCompression         bool   // Compress layers when saving to the dir: transport
 	dirForceDecompression       bool   // Decompress layers when saving to the dir: transport
 	ociAcceptUncompressedLayers bool   // Whether to accept uncompressed layers in the oci: transport
-	compressionFormat           string // Format to use for the compression
-	compressionLevel            *int   // Level to use for the compression
+	CompressionFormat           string // Format to use for the compression
+	CompressionLevel            *int   // Level to use for the compression
 	precomputeDigests           bool   // Precompute digests to dedup layers when saving to the docker: transport
 }
 
-// newSystemContext returns a *types.SystemContext corresponding to opts.
+// NewSystemContext returns a *types.SystemContext corresponding to opts, including the destination-only options.
 // It is guaranteed to return a fresh instance, so it is safe to make additional updates to it.
-func (opts *ImageDestOptions) newSystemContext() (*types.SystemContext, error) {
+func (opts *ImageDestOptions) NewSystemContext() (*types.SystemContext, error) {
 	ctx, err := opts.ImageOptions.NewSystemContext()
 	if err != nil {
 		return nil, err
@@ -127,15 +128,15 @@ func (opts *ImageDestOptions) newSystemContext() (*types.SystemContext, error) {
 	ctx.DirForceCompress = opts.dirForceCompression
 	ctx.DirForceDecompress = opts.dirForceDecompression
 	ctx.OCIAcceptUncompressedLayers = opts.ociAcceptUncompressedLayers
-	if opts.compressionFormat != "" {
-		cf, err := compression.AlgorithmByName(opts.compressionFormat)
+	if opts.CompressionFormat != "" {
+		cf, err := compression.AlgorithmByName(opts.CompressionFormat)
 		if err != nil {
 			return nil, err
 		}
 		ctx.CompressionFormat = &cf
 	}
-	if opts.compressionLevel != nil {
-		value := opts.compressionLevel
+	if opts.CompressionLevel != nil {
+		value := opts.CompressionLevel
 		ctx.CompressionLevel = value
 	}
 	return ctx, err
@@ -166,9 +167,28 @@ func getDockerAuth(creds string) (*types.DockerAuthConfig, error) {
 	}, nil
 }
 
+// CheckShortName fails if opts reject short names and name is a docker: image which does not include a
+// registry, and so would be resolved to docker.io.
+func CheckShortName(opts *GlobalOptions, name string) error {
+	if !opts.RejectShortNames {
+		return nil
+	}
+	transport, image, found := strings.Cut(name, ":")
+	if !found || transport != "docker" {
+		return nil
+	}
+	if _, err := reference.ParseNamed(strings.TrimPrefix(image, "//")); errors.Is(err, reference.ErrNameNotCanonical) {
+		return fmt.Errorf("short name %s is rejected, use a fully qualified image name including the registry", name)
+	}
+	return nil
+}
+
 // ParseImageSource converts image URL-like string to an ImageSource.
 // The caller must call .Close() on the returned ImageSource.
 func ParseImageSource(ctx context.Context, opts *ImageOptions, name string) (types.ImageSource, error) {
+	if err := CheckShortName(opts.Global, name); err != nil {
+		return nil, err
+	}
 	ref, err := alltransports.ParseImageName(name)
 	if err != nil {
 		return nil, err